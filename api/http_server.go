@@ -37,19 +37,54 @@ func New(daemonSocket string, data *core.Data) API {
 	srv.router.HandleFunc("/v1/devices/{name}", srv.getDevice).Methods("GET")
 	srv.router.HandleFunc("/v1/devices/{name}", srv.delDevice).Methods("DELETE")
 	srv.router.HandleFunc("/v1/devices/{name}", srv.setDevice).Methods("PUT")
+	srv.router.HandleFunc("/v1/devices/{name}/run", srv.runDevice).Methods("POST")
 	srv.router.HandleFunc("/v1/programs", srv.listPrograms).Methods("GET")
 	srv.router.HandleFunc("/v1/programs", srv.createProgram).Methods("POST")
 	srv.router.HandleFunc("/v1/programs/{name}", srv.getProgram).Methods("GET")
 	srv.router.HandleFunc("/v1/programs/{name}", srv.delProgram).Methods("DELETE")
+	srv.router.HandleFunc("/v1/programs/{name}", srv.setProgram).Methods("PUT")
 	srv.router.HandleFunc("/v1/programs/{name}/start", srv.startProgram).Methods("POST")
 	srv.router.HandleFunc("/v1/programs/{name}/stop", srv.stopProgram).Methods("POST")
+	srv.router.HandleFunc("/v1/programs/{name}/pause", srv.pauseProgram).Methods("POST")
+	srv.router.HandleFunc("/v1/programs/{name}/resume", srv.resumeProgram).Methods("POST")
+	srv.router.HandleFunc("/v1/programs/{name}/next", srv.nextProgramStep).Methods("POST")
+	srv.router.HandleFunc("/v1/programs/{name}/prev", srv.prevProgramStep).Methods("POST")
 	srv.router.HandleFunc("/v1/programs/{name}/devices", srv.addDeviceToProgram).Methods("POST")
+	srv.router.HandleFunc("/v1/programs/{name}/devices", srv.setProgramDevices).Methods("PUT")
 	srv.router.HandleFunc("/v1/programs/{name}/devices/{idx}", srv.delDeviceFromProgram).Methods("DELETE")
+	srv.router.HandleFunc("/v1/programs/{name}/devices/{idx}", srv.insertDeviceToProgram).Methods("POST")
+	srv.router.HandleFunc("/v1/programs/{name}/devices/{idx}", srv.setProgramDevice).Methods("PUT")
+	srv.router.HandleFunc("/v1/programs/{name}/devices/{idx}/move", srv.moveProgramDevice).Methods("POST")
+	srv.router.HandleFunc("/v1/programs/{name}/adjustment", srv.getProgramAdjustment).Methods("GET")
+	srv.router.HandleFunc("/v1/programs/{name}/adjustment", srv.setProgramAdjustment).Methods("PUT")
 	srv.router.HandleFunc("/v1/schedules", srv.listSchedules).Methods("GET")
 	srv.router.HandleFunc("/v1/schedules", srv.createSchedule).Methods("POST")
 	srv.router.HandleFunc("/v1/schedules/{name}", srv.getSchedule).Methods("GET")
 	srv.router.HandleFunc("/v1/schedules/{name}", srv.delSchedule).Methods("DELETE")
 	srv.router.HandleFunc("/v1/schedules/{name}", srv.setSchedule).Methods("PUT")
+	srv.router.HandleFunc("/v1/schedules/{name}/skip", srv.skipSchedule).Methods("POST")
+	srv.router.HandleFunc("/v1/schedules/{name}/pause", srv.pauseSchedule).Methods("POST")
+	srv.router.HandleFunc("/v1/schedules/{name}/pause", srv.unpauseSchedule).Methods("DELETE")
+	srv.router.HandleFunc("/v1/sensors", srv.listSensors).Methods("GET")
+	srv.router.HandleFunc("/v1/sensors", srv.addSensor).Methods("POST")
+	srv.router.HandleFunc("/v1/sensors/{name}", srv.getSensor).Methods("GET")
+	srv.router.HandleFunc("/v1/sensors/{name}", srv.delSensor).Methods("DELETE")
+	srv.router.HandleFunc("/v1/settings", srv.getSettings).Methods("GET")
+	srv.router.HandleFunc("/v1/settings", srv.setSettings).Methods("PUT")
+	srv.router.HandleFunc("/v1/raindelay", srv.getRainDelay).Methods("GET")
+	srv.router.HandleFunc("/v1/raindelay", srv.setRainDelay).Methods("PUT")
+	srv.router.HandleFunc("/v1/raindelay", srv.clearRainDelay).Methods("DELETE")
+	srv.router.HandleFunc("/v1/adjustment", srv.getAdjustment).Methods("GET")
+	srv.router.HandleFunc("/v1/adjustment", srv.setAdjustment).Methods("PUT")
+	srv.router.HandleFunc("/v1/blackouts", srv.getBlackouts).Methods("GET")
+	srv.router.HandleFunc("/v1/blackouts", srv.setBlackouts).Methods("PUT")
+	srv.router.HandleFunc("/v1/calendar", srv.getCalendar).Methods("GET")
+	srv.router.HandleFunc("/v1/usage", srv.getUsage).Methods("GET")
+	srv.router.HandleFunc("/v1/events", srv.listEvents).Methods("GET")
+	srv.router.HandleFunc("/v1/events", srv.clearEvents).Methods("DELETE")
+	srv.router.HandleFunc("/v1/queue", srv.listQueue).Methods("GET")
+	srv.router.HandleFunc("/v1/queue", srv.clearQueue).Methods("DELETE")
+	srv.router.HandleFunc("/v1/queue/{idx}", srv.delQueueEntry).Methods("DELETE")
 
 	srv.server = &http.Server{
 		Handler:      srv.router,
@@ -118,13 +153,65 @@ func (s *httpServer) setDevice(w http.ResponseWriter, r *http.Request) {
 	dev := &core.Device{}
 	err := json.NewDecoder(r.Body).Decode(dev)
 	if err == nil {
+		if dev.IsMaster() && s.data.Programs.IsDeviceInUse(name) {
+			s.sendResponse(w, r, core.DeviceInUse, nil)
+			return
+		}
 		err = s.data.Devices.Set(name, dev)
 	}
 	s.sendResponse(w, r, err, nil)
 }
 
+func (s *httpServer) runDevice(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	name := vars["name"]
+
+	data := make(map[string]string)
+	err := json.NewDecoder(r.Body).Decode(&data)
+	if err == nil {
+		dur, _ := time.ParseDuration(data["duration"])
+		dev, err := s.data.Devices.Get(name)
+		if err != nil {
+			s.sendResponse(w, r, err, nil)
+			return
+		}
+		err = dev.Run(dur)
+		s.sendResponse(w, r, err, nil)
+		return
+	}
+	s.sendResponse(w, r, err, nil)
+}
+
+// programBody is a program with its live status
+type programBody struct {
+	program *core.Program
+	Status  *core.ProgramStatus `json:"status"`
+}
+
+func (b *programBody) MarshalJSON() ([]byte, error) {
+	type body programBody
+	return mergeJson(b.program, (*body)(b))
+}
+
+// programDetails is a program with its live status, its scale and timeline
+type programDetails struct {
+	program  *core.Program
+	Status   *core.ProgramStatus `json:"status"`
+	Scale    int                 `json:"scale"`
+	Timeline []*core.Step        `json:"timeline"`
+}
+
+func (d *programDetails) MarshalJSON() ([]byte, error) {
+	type details programDetails
+	return mergeJson(d.program, (*details)(d))
+}
+
 func (s *httpServer) listPrograms(w http.ResponseWriter, r *http.Request) {
-	s.sendResponse(w, r, nil, s.data.Programs)
+	progs := make(map[string]*programBody)
+	for _, prg := range s.data.Programs.List() {
+		progs[prg.Name] = &programBody{prg, prg.Status()}
+	}
+	s.sendResponse(w, r, nil, progs)
 }
 
 func (s *httpServer) createProgram(w http.ResponseWriter, r *http.Request) {
@@ -140,7 +227,34 @@ func (s *httpServer) getProgram(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	name := vars["name"]
 	prg, err := s.data.Programs.Get(name)
-	s.sendResponse(w, r, err, prg)
+	if err != nil {
+		s.sendResponse(w, r, err, nil)
+		return
+	}
+
+	details := &programDetails{prg, prg.Status(), prg.Scale(time.Now()), prg.Timeline()}
+	s.sendResponse(w, r, nil, details)
+}
+
+// setProgram sets the delay and the overlap of the steps of the program, the
+// elements are edited through the devices of the program
+func (s *httpServer) setProgram(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	name := vars["name"]
+
+	data := &core.Program{}
+	err := json.NewDecoder(r.Body).Decode(data)
+	if err == nil {
+		prg, err := s.data.Programs.Get(name)
+		if err != nil {
+			s.sendResponse(w, r, err, nil)
+			return
+		}
+		err = prg.SetDelay(data.Delay, data.Overlap)
+		s.sendResponse(w, r, err, nil)
+		return
+	}
+	s.sendResponse(w, r, err, nil)
 }
 
 func (s *httpServer) delProgram(w http.ResponseWriter, r *http.Request) {
@@ -154,10 +268,18 @@ func (s *httpServer) startProgram(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	name := vars["name"]
 	prg, err := s.data.Programs.Get(name)
-	if err == nil {
-		prg.Start()
+	if err != nil {
+		s.sendResponse(w, r, err, nil)
+		return
 	}
-	s.sendResponse(w, r, err, nil)
+	result, err := s.data.Queue.Submit(prg, "api")
+	if err != nil {
+		s.sendResponse(w, r, err, nil)
+		return
+	}
+	s.sendResponse(w, r, nil, struct {
+		Result string `json:"result"`
+	}{result})
 }
 
 func (s *httpServer) stopProgram(w http.ResponseWriter, r *http.Request) {
@@ -165,31 +287,196 @@ func (s *httpServer) stopProgram(w http.ResponseWriter, r *http.Request) {
 	name := vars["name"]
 	prg, err := s.data.Programs.Get(name)
 	if err == nil {
+		s.data.Queue.Cancel(prg)
 		prg.Stop()
 	}
 	s.sendResponse(w, r, err, nil)
 }
 
+func (s *httpServer) pauseProgram(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	name := vars["name"]
+	prg, err := s.data.Programs.Get(name)
+	if err == nil {
+		err = prg.Pause()
+	}
+	s.sendResponse(w, r, err, nil)
+}
+
+func (s *httpServer) resumeProgram(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	name := vars["name"]
+	prg, err := s.data.Programs.Get(name)
+	if err != nil {
+		s.sendResponse(w, r, err, nil)
+		return
+	}
+	result, err := prg.Resume("api")
+	if err != nil {
+		s.sendResponse(w, r, err, nil)
+		return
+	}
+	s.sendResponse(w, r, nil, struct {
+		Result string `json:"result"`
+	}{result})
+}
+
+func (s *httpServer) nextProgramStep(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	name := vars["name"]
+	prg, err := s.data.Programs.Get(name)
+	if err == nil {
+		err = prg.Next()
+	}
+	s.sendResponse(w, r, err, nil)
+}
+
+func (s *httpServer) prevProgramStep(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	name := vars["name"]
+	prg, err := s.data.Programs.Get(name)
+	if err == nil {
+		err = prg.Prev()
+	}
+	s.sendResponse(w, r, err, nil)
+}
+
+// elementBody is a program element with its devices given by name and its
+// durations as strings, e.g.: 5m
+type elementBody struct {
+	Device   string   `json:"device"`
+	Duration string   `json:"duration"`
+	Parallel []string `json:"parallel"`
+	Cycle    string   `json:"cycle"`
+	Soak     string   `json:"soak"`
+	Volume   float64  `json:"volume"`
+}
+
+// element looks up the devices of the element
+func (s *httpServer) element(data *elementBody) (*core.ProgramElement, error) {
+	dur, _ := time.ParseDuration(data.Duration)
+	cycle, _ := time.ParseDuration(data.Cycle)
+	soak, _ := time.ParseDuration(data.Soak)
+
+	dev, err := s.data.Devices.Get(data.Device)
+	if err != nil {
+		return nil, err
+	}
+	parallel := []*core.Device{}
+	for _, devName := range data.Parallel {
+		pdev, err := s.data.Devices.Get(devName)
+		if err != nil {
+			return nil, err
+		}
+		parallel = append(parallel, pdev)
+	}
+	return &core.ProgramElement{Device: dev, Parallel: parallel, Duration: dur, Cycle: cycle, Soak: soak, Volume: data.Volume}, nil
+}
+
 func (s *httpServer) addDeviceToProgram(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	name := vars["name"]
 
-	data := make(map[string]string)
-	err := json.NewDecoder(r.Body).Decode(&data)
-	dur, _ := time.ParseDuration(data["duration"])
+	data := &elementBody{}
+	err := json.NewDecoder(r.Body).Decode(data)
+	if err == nil {
+		prg, err := s.data.Programs.Get(name)
+		if err != nil {
+			s.sendResponse(w, r, err, nil)
+			return
+		}
+		elem, err := s.element(data)
+		if err == nil {
+			err = prg.AddElement(elem)
+		}
+		s.sendResponse(w, r, err, nil)
+		return
+	}
+	s.sendResponse(w, r, err, nil)
+}
 
+func (s *httpServer) setProgramDevices(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	name := vars["name"]
+
+	data := []*elementBody{}
+	err := json.NewDecoder(r.Body).Decode(&data)
 	if err == nil {
 		prg, err := s.data.Programs.Get(name)
 		if err != nil {
 			s.sendResponse(w, r, err, nil)
 			return
 		}
-		dev, err := s.data.Devices.Get(data["device"])
+		elems := []*core.ProgramElement{}
+		for _, d := range data {
+			elem, err := s.element(d)
+			if err != nil {
+				s.sendResponse(w, r, err, nil)
+				return
+			}
+			elems = append(elems, elem)
+		}
+		err = prg.SetElements(elems)
+		s.sendResponse(w, r, err, nil)
+		return
+	}
+	s.sendResponse(w, r, err, nil)
+}
+
+func (s *httpServer) insertDeviceToProgram(w http.ResponseWriter, r *http.Request) {
+	s.editProgramDevice(w, r, (*core.Program).InsertElement)
+}
+
+func (s *httpServer) setProgramDevice(w http.ResponseWriter, r *http.Request) {
+	s.editProgramDevice(w, r, (*core.Program).SetElement)
+}
+
+// editProgramDevice inserts or replaces the element at the index given in
+// the path
+func (s *httpServer) editProgramDevice(w http.ResponseWriter, r *http.Request, edit func(*core.Program, int, *core.ProgramElement) error) {
+	vars := mux.Vars(r)
+	name := vars["name"]
+	idx, err := strconv.Atoi(vars["idx"])
+	if err != nil {
+		s.sendResponse(w, r, core.OutOfRange, nil)
+		return
+	}
+
+	data := &elementBody{}
+	err = json.NewDecoder(r.Body).Decode(data)
+	if err == nil {
+		prg, err := s.data.Programs.Get(name)
 		if err != nil {
 			s.sendResponse(w, r, err, nil)
 			return
 		}
-		err = prg.AddDevice(dev, dur)
+		elem, err := s.element(data)
+		if err == nil {
+			err = edit(prg, idx, elem)
+		}
+		s.sendResponse(w, r, err, nil)
+		return
+	}
+	s.sendResponse(w, r, err, nil)
+}
+
+func (s *httpServer) moveProgramDevice(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	name := vars["name"]
+	from, err := strconv.Atoi(vars["idx"])
+	if err != nil {
+		s.sendResponse(w, r, core.OutOfRange, nil)
+		return
+	}
+	to, err := strconv.Atoi(r.URL.Query().Get("to"))
+	if err != nil {
+		s.sendResponse(w, r, core.OutOfRange, nil)
+		return
+	}
+
+	prg, err := s.data.Programs.Get(name)
+	if err == nil {
+		err = prg.MoveElement(from, to)
 	}
 	s.sendResponse(w, r, err, nil)
 }
@@ -197,7 +484,11 @@ func (s *httpServer) addDeviceToProgram(w http.ResponseWriter, r *http.Request)
 func (s *httpServer) delDeviceFromProgram(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	name := vars["name"]
-	idx, _ := strconv.Atoi(vars["idx"])
+	idx, err := strconv.Atoi(vars["idx"])
+	if err != nil {
+		s.sendResponse(w, r, core.OutOfRange, nil)
+		return
+	}
 
 	prg, err := s.data.Programs.Get(name)
 	if err == nil {
@@ -206,8 +497,61 @@ func (s *httpServer) delDeviceFromProgram(w http.ResponseWriter, r *http.Request
 	s.sendResponse(w, r, err, nil)
 }
 
+func (s *httpServer) getProgramAdjustment(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	name := vars["name"]
+	prg, err := s.data.Programs.Get(name)
+	if err != nil {
+		s.sendResponse(w, r, err, nil)
+		return
+	}
+	s.sendResponse(w, r, nil, adjustmentBody(prg.GetAdjustment()))
+}
+
+func (s *httpServer) setProgramAdjustment(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	name := vars["name"]
+
+	adj := core.Adjustment{}
+	err := json.NewDecoder(r.Body).Decode(&adj)
+	if err == nil {
+		prg, err := s.data.Programs.Get(name)
+		if err != nil {
+			s.sendResponse(w, r, err, nil)
+			return
+		}
+		err = prg.SetAdjustment(adj)
+		s.sendResponse(w, r, err, nil)
+		return
+	}
+	s.sendResponse(w, r, err, nil)
+}
+
+// scheduleBody adds the time of the next run to a schedule
+type scheduleBody struct {
+	schedule *core.Schedule
+	Next     *time.Time `json:"next,omitempty"`
+}
+
+func newScheduleBody(sch *core.Schedule) *scheduleBody {
+	body := &scheduleBody{schedule: sch}
+	if next := sch.GetNext(); !next.IsZero() {
+		body.Next = &next
+	}
+	return body
+}
+
+func (b *scheduleBody) MarshalJSON() ([]byte, error) {
+	type body scheduleBody
+	return mergeJson(b.schedule, (*body)(b))
+}
+
 func (s *httpServer) listSchedules(w http.ResponseWriter, r *http.Request) {
-	s.sendResponse(w, r, nil, s.data.Schedules)
+	schs := make(map[string]*scheduleBody)
+	for _, sch := range s.data.Schedules.List() {
+		schs[sch.Name] = newScheduleBody(sch)
+	}
+	s.sendResponse(w, r, nil, schs)
 }
 
 func (s *httpServer) createSchedule(w http.ResponseWriter, r *http.Request) {
@@ -231,7 +575,11 @@ func (s *httpServer) getSchedule(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	name := vars["name"]
 	sch, err := s.data.Schedules.Get(name)
-	s.sendResponse(w, r, err, sch)
+	if err != nil {
+		s.sendResponse(w, r, err, nil)
+		return
+	}
+	s.sendResponse(w, r, nil, newScheduleBody(sch))
 }
 
 func (s *httpServer) delSchedule(w http.ResponseWriter, r *http.Request) {
@@ -261,6 +609,272 @@ func (s *httpServer) setSchedule(w http.ResponseWriter, r *http.Request) {
 	s.sendResponse(w, r, err, nil)
 }
 
+// skipSchedule makes the schedule skip its next runs, as many as the count
+// query says, one by default
+func (s *httpServer) skipSchedule(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	sch, err := s.data.Schedules.Get(vars["name"])
+	if err != nil {
+		s.sendResponse(w, r, err, nil)
+		return
+	}
+
+	count := 1
+	if str := r.URL.Query().Get("count"); str != "" {
+		count, err = strconv.Atoi(str)
+		if err != nil {
+			s.sendResponse(w, r, core.InvalidSkipCount, nil)
+			return
+		}
+	}
+	err = sch.SkipRuns(count)
+	s.sendResponse(w, r, err, nil)
+}
+
+// pauseSchedule makes the schedule skip its runs until the time of the until
+// query
+func (s *httpServer) pauseSchedule(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	sch, err := s.data.Schedules.Get(vars["name"])
+	if err != nil {
+		s.sendResponse(w, r, err, nil)
+		return
+	}
+
+	until, err := time.Parse(time.RFC3339, r.URL.Query().Get("until"))
+	if err != nil {
+		s.sendResponse(w, r, core.InvalidTime, nil)
+		return
+	}
+	sch.Pause(until)
+	s.sendResponse(w, r, nil, nil)
+}
+
+func (s *httpServer) unpauseSchedule(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	sch, err := s.data.Schedules.Get(vars["name"])
+	if err == nil {
+		sch.Unpause()
+	}
+	s.sendResponse(w, r, err, nil)
+}
+
+func (s *httpServer) listSensors(w http.ResponseWriter, r *http.Request) {
+	s.sendResponse(w, r, nil, s.data.Sensors)
+}
+
+func (s *httpServer) addSensor(w http.ResponseWriter, r *http.Request) {
+	sensor := &core.Sensor{}
+	err := json.NewDecoder(r.Body).Decode(sensor)
+	if err == nil {
+		err = s.data.Sensors.Add(sensor)
+	}
+	s.sendResponse(w, r, err, nil)
+}
+
+func (s *httpServer) getSensor(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	name := vars["name"]
+	sensor, err := s.data.Sensors.Get(name)
+	s.sendResponse(w, r, err, sensor)
+}
+
+func (s *httpServer) delSensor(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	name := vars["name"]
+	err := s.data.Sensors.Del(name)
+	s.sendResponse(w, r, err, nil)
+}
+
+func (s *httpServer) getSettings(w http.ResponseWriter, r *http.Request) {
+	s.sendResponse(w, r, nil, s.data.Settings)
+}
+
+func (s *httpServer) setSettings(w http.ResponseWriter, r *http.Request) {
+	set := &core.Settings{}
+	err := json.NewDecoder(r.Body).Decode(set)
+	if err == nil {
+		err = s.data.Settings.Set(set)
+	}
+	s.sendResponse(w, r, err, nil)
+}
+
+// rainDelay is the body of the rain delay requests, either the end of the
+// delay or its duration from now is set
+type rainDelay struct {
+	Until *time.Time `json:"until,omitempty"`
+	Delay string     `json:"delay,omitempty"`
+}
+
+func (s *httpServer) getRainDelay(w http.ResponseWriter, r *http.Request) {
+	body := &rainDelay{}
+	if until, delayed := s.data.Settings.GetRainDelay(time.Now()); delayed {
+		body.Until = &until
+	}
+	s.sendResponse(w, r, nil, body)
+}
+
+func (s *httpServer) setRainDelay(w http.ResponseWriter, r *http.Request) {
+	body := &rainDelay{}
+	err := json.NewDecoder(r.Body).Decode(body)
+	if err == nil {
+		var until time.Time
+		if body.Until != nil {
+			until = *body.Until
+		} else {
+			var delay time.Duration
+			delay, err = time.ParseDuration(body.Delay)
+			if err != nil || delay <= 0 {
+				s.sendResponse(w, r, core.InvalidDuration, nil)
+				return
+			}
+			until = time.Now().Add(delay)
+		}
+		s.data.Settings.SetRainDelay(until)
+	}
+	s.sendResponse(w, r, err, nil)
+}
+
+func (s *httpServer) clearRainDelay(w http.ResponseWriter, r *http.Request) {
+	s.data.Settings.ClearRainDelay()
+	s.sendResponse(w, r, nil, nil)
+}
+
+func (s *httpServer) getAdjustment(w http.ResponseWriter, r *http.Request) {
+	s.sendResponse(w, r, nil, adjustmentBody(s.data.Settings.GetAdjustment()))
+}
+
+func (s *httpServer) setAdjustment(w http.ResponseWriter, r *http.Request) {
+	adj := core.Adjustment{}
+	err := json.NewDecoder(r.Body).Decode(&adj)
+	if err == nil {
+		err = s.data.Settings.SetAdjustment(adj)
+	}
+	s.sendResponse(w, r, err, nil)
+}
+
+func (s *httpServer) getBlackouts(w http.ResponseWriter, r *http.Request) {
+	blackouts := s.data.Settings.GetBlackouts()
+	if blackouts == nil {
+		blackouts = []*core.Blackout{}
+	}
+	s.sendResponse(w, r, nil, blackouts)
+}
+
+func (s *httpServer) setBlackouts(w http.ResponseWriter, r *http.Request) {
+	blackouts := []*core.Blackout{}
+	err := json.NewDecoder(r.Body).Decode(&blackouts)
+	if err == nil {
+		err = s.data.Settings.SetBlackouts(blackouts)
+	}
+	s.sendResponse(w, r, err, nil)
+}
+
+// getCalendar lists the expected program runs between the from and to days
+// of the query, from defaults to now, to defaults to a week later
+func (s *httpServer) getCalendar(w http.ResponseWriter, r *http.Request) {
+	from := time.Now()
+	var err error
+	if str := r.URL.Query().Get("from"); str != "" {
+		from, err = core.ParseDay(str)
+	}
+	to := from.AddDate(0, 0, 7)
+	if str := r.URL.Query().Get("to"); str != "" && err == nil {
+		to, err = core.ParseDay(str)
+		to = to.AddDate(0, 0, 1).Add(-time.Second)
+	}
+	if err != nil {
+		s.sendResponse(w, r, err, nil)
+		return
+	}
+	s.sendResponse(w, r, nil, s.data.Schedules.Calendar(from, to))
+}
+
+// getUsage reports the water used between the from and to days of the
+// query, both default to today
+func (s *httpServer) getUsage(w http.ResponseWriter, r *http.Request) {
+	from, to := time.Now(), time.Now()
+	var err error
+	if str := r.URL.Query().Get("from"); str != "" {
+		from, err = core.ParseDay(str)
+	}
+	if str := r.URL.Query().Get("to"); str != "" && err == nil {
+		to, err = core.ParseDay(str)
+	}
+	if err != nil {
+		s.sendResponse(w, r, err, nil)
+		return
+	}
+	s.sendResponse(w, r, nil, s.data.Usage.Report(from, to))
+}
+
+// listEvents returns the events recorded since the time of the since query,
+// every event by default
+func (s *httpServer) listEvents(w http.ResponseWriter, r *http.Request) {
+	var since time.Time
+	if str := r.URL.Query().Get("since"); str != "" {
+		var err error
+		since, err = time.Parse(time.RFC3339, str)
+		if err != nil {
+			s.sendResponse(w, r, core.InvalidTime, nil)
+			return
+		}
+	}
+	s.sendResponse(w, r, nil, s.data.Events.Get(since))
+}
+
+func (s *httpServer) clearEvents(w http.ResponseWriter, r *http.Request) {
+	s.data.Events.Clear()
+	s.sendResponse(w, r, nil, nil)
+}
+
+func (s *httpServer) listQueue(w http.ResponseWriter, r *http.Request) {
+	s.sendResponse(w, r, nil, s.data.Queue.List())
+}
+
+func (s *httpServer) clearQueue(w http.ResponseWriter, r *http.Request) {
+	s.data.Queue.Clear()
+	s.sendResponse(w, r, nil, nil)
+}
+
+func (s *httpServer) delQueueEntry(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	idx, err := strconv.Atoi(vars["idx"])
+	if err != nil {
+		s.sendResponse(w, r, core.QueueOutOfRange, nil)
+		return
+	}
+	err = s.data.Queue.Del(idx)
+	s.sendResponse(w, r, err, nil)
+}
+
+// mergeJson encodes the values as one json object, the fields of the later
+// values override the earlier ones, the core types encoded under their lock
+// can not be embedded as their MarshalJSON would hide the other fields
+func mergeJson(values ...interface{}) ([]byte, error) {
+	fields := make(map[string]json.RawMessage)
+	for _, v := range values {
+		js, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		err = json.Unmarshal(js, &fields)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return json.Marshal(fields)
+}
+
+// adjustmentBody makes sure an empty adjustment table is sent as an empty
+// json object
+func adjustmentBody(adj core.Adjustment) core.Adjustment {
+	if adj == nil {
+		return core.Adjustment{}
+	}
+	return adj
+}
+
 func (s *httpServer) sendResponse(w http.ResponseWriter, r *http.Request, err error, body interface{}) {
 
 	switch err {
@@ -273,7 +887,7 @@ func (s *httpServer) sendResponse(w http.ResponseWriter, r *http.Request, err er
 	case core.NotFound:
 		log.Printf("%s %s -> %s ", r.Method, r.URL, err.Error())
 		http.Error(w, err.Error(), http.StatusNotFound)
-	case core.OutOfRange:
+	case core.OutOfRange, core.QueueOutOfRange:
 		log.Printf("%s %s -> %s ", r.Method, r.URL, err.Error())
 		http.Error(w, err.Error(), http.StatusNotFound)
 	case core.AlreadyExists:
@@ -282,6 +896,16 @@ func (s *httpServer) sendResponse(w http.ResponseWriter, r *http.Request, err er
 	case core.DeviceInUse:
 		log.Printf("%s %s -> %s ", r.Method, r.URL, err.Error())
 		http.Error(w, err.Error(), http.StatusNotAcceptable)
+	case core.DeviceIsMaster:
+		log.Printf("%s %s -> %s ", r.Method, r.URL, err.Error())
+		http.Error(w, err.Error(), http.StatusNotAcceptable)
+	case core.ProgramRunning, core.OtherProgramRunning, core.NotRunning, core.NotPaused:
+		log.Printf("%s %s -> %s ", r.Method, r.URL, err.Error())
+		http.Error(w, err.Error(), http.StatusNotAcceptable)
+	case core.InvalidRole, core.InvalidFlow, core.InvalidVolume, core.InvalidSettings, core.InvalidDuration, core.InvalidAdjustment, core.InvalidSensor, core.InvalidDay, core.InvalidTime, core.InvalidSpec,
+		core.InvalidBlackout, core.InvalidActiveRange, core.InvalidSkipCount, core.TimeInPast:
+		log.Printf("%s %s -> %s ", r.Method, r.URL, err.Error())
+		http.Error(w, err.Error(), http.StatusBadRequest)
 	default:
 		log.Printf("%s %s -> %s ", r.Method, r.URL, err.Error())
 		http.Error(w, err.Error(), http.StatusInternalServerError)
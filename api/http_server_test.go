@@ -95,11 +95,51 @@ func TestApiAddDelDevice(t *testing.T) {
 	req(t, "GET", "/v1/devices/dev1", "", 404, "Not found")
 }
 
+func TestApiDeviceRole(t *testing.T) {
+	req(t, "POST", "/v1/devices", "{\"name\":\"dev1\", \"pin\":1, \"role\":\"invalid\"}", 400, "Invalid device role")
+	req(t, "POST", "/v1/devices", "{\"name\":\"pump\", \"pin\":1, \"role\":\"pump\", \"lead\":2000000000}", 200, "")
+	req(t, "GET", "/v1/devices/pump", "", 200, "{\"name\":\"pump\", \"role\":\"pump\", \"lead\":2000000000}")
+	req(t, "POST", "/v1/devices", "{\"name\":\"dev1\", \"pin\":2}", 200, "")
+	req(t, "POST", "/v1/programs", "{\"name\":\"pr1\"}", 200, "")
+
+	req(t, "POST", "/v1/programs/pr1/devices", "{\"device\":\"pump\", \"duration\":\"5s\"}", 406, "Master devices can not be added to programs")
+	req(t, "POST", "/v1/programs/pr1/devices", "{\"device\":\"dev1\", \"duration\":\"5s\"}", 200, "")
+	req(t, "PUT", "/v1/devices/dev1", "{\"pin\":2, \"role\":\"master\"}", 406, "Device is in use")
+
+	// cleanup
+	req(t, "DELETE", "/v1/programs/pr1", "", 200, "")
+	req(t, "DELETE", "/v1/devices/dev1", "", 200, "")
+	req(t, "DELETE", "/v1/devices/pump", "", 200, "")
+}
+
+func TestApiDeviceMaxOn(t *testing.T) {
+	req(t, "POST", "/v1/devices", "{\"name\":\"dev1\", \"pin\":1, \"max-on\":100000000}", 200, "")
+	req(t, "PUT", "/v1/devices/dev1", "{\"pin\":1, \"on\":true, \"max-on\":100000000}", 200, "")
+	req(t, "GET", "/v1/devices/dev1", "", 200, "{\"name\":\"dev1\", \"on\":true, \"max-on\":100000000}")
+	time.Sleep(200 * time.Millisecond)
+	req(t, "GET", "/v1/devices/dev1", "", 200, "{\"name\":\"dev1\", \"on\":false}")
+	req(t, "GET", "/v1/events", "", 200, "\"type\":\"max-on\",\"device\":\"dev1\"")
+	req(t, "DELETE", "/v1/events", "", 200, "")
+	req(t, "DELETE", "/v1/devices/dev1", "", 200, "")
+}
+
+func TestApiDeviceRun(t *testing.T) {
+	req(t, "POST", "/v1/devices", "{\"name\":\"dev1\", \"pin\":1}", 200, "")
+	req(t, "POST", "/v1/devices/dev-whatever/run", "{\"duration\":\"1s\"}", 404, "Not found")
+	req(t, "POST", "/v1/devices/dev1/run", "{\"duration\":\"invalid\"}", 400, "Invalid duration")
+	req(t, "POST", "/v1/devices/dev1/run", "{\"duration\":\"200ms\"}", 200, "")
+	time.Sleep(100 * time.Millisecond)
+	req(t, "GET", "/v1/devices/dev1", "", 200, "{\"name\":\"dev1\", \"on\":true}")
+	time.Sleep(200 * time.Millisecond)
+	req(t, "GET", "/v1/devices/dev1", "", 200, "{\"name\":\"dev1\", \"on\":false}")
+	req(t, "DELETE", "/v1/devices/dev1", "", 200, "")
+}
+
 func TestApiAddDelProgram(t *testing.T) {
 	req(t, "POST", "/v1/programs", "{\"name\":\"pr1\"}", 200, "")
 	req(t, "GET", "/v1/programs/pr1", "", 200, "{\"name\":\"pr1\"}")
 
-	req(t, "GET", "/v1/programs", "", 200, "{\"pr1\":{\"name\":\"pr1\"}}")
+	req(t, "GET", "/v1/programs", "", 200, "{\"pr1\":{\"name\":\"pr1\", \"status\":{\"state\":\"idle\"}}}")
 
 	req(t, "DELETE", "/v1/programs/pr1", "", 200, "")
 	req(t, "GET", "/v1/programs/pr1", "", 404, "Not found")
@@ -120,6 +160,10 @@ func TestApiAddDelDeviceToProgram(t *testing.T) {
 
 	req(t, "DELETE", "/v1/programs/2", "", 404, "Not found")
 
+	// the elements are deleted by their index, not by their device
+	req(t, "DELETE", "/v1/programs/pr1/devices/dev1", "", 404, "Element index out of range")
+	req(t, "GET", "/v1/programs/pr1", "", 200, "{\"devices\":[{\"device\":\"dev1\",\"duration\":5000000000},{\"device\":\"dev2\",\"duration\":8000000000}]}")
+
 	req(t, "DELETE", "/v1/programs/pr1/devices/0", "", 200, "")
 	req(t, "DELETE", "/v1/programs/pr1/devices/0", "", 200, "")
 	req(t, "DELETE", "/v1/programs/pr1/devices/0", "", 404, "Element index out of range")
@@ -132,6 +176,245 @@ func TestApiAddDelDeviceToProgram(t *testing.T) {
 	req(t, "DELETE", "/v1/programs/pr1/devices/0", "", 404, "Not found")
 }
 
+func TestApiProgramDelay(t *testing.T) {
+	req(t, "POST", "/v1/devices", "{\"name\":\"dev1\", \"pin\":1, \"close-settle\":5000000000}", 200, "")
+	req(t, "POST", "/v1/devices", "{\"name\":\"dev2\", \"pin\":2, \"open-settle\":-1}", 400, "Invalid duration")
+	req(t, "POST", "/v1/devices", "{\"name\":\"dev2\", \"pin\":2}", 200, "")
+	req(t, "PUT", "/v1/devices/dev2", "{\"name\":\"dev2\", \"pin\":2, \"open-settle\":2000000000}", 200, "")
+	req(t, "GET", "/v1/devices/dev2", "", 200, "{\"name\":\"dev2\", \"open-settle\":2000000000}")
+	req(t, "POST", "/v1/programs", "{\"name\":\"pr1\"}", 200, "")
+	req(t, "POST", "/v1/programs/pr1/devices", "{\"device\":\"dev1\", \"duration\":\"1m\"}", 200, "")
+	req(t, "POST", "/v1/programs/pr1/devices", "{\"device\":\"dev2\", \"duration\":\"1m\"}", 200, "")
+
+	req(t, "PUT", "/v1/programs/pr-whatever", "{\"delay\":10000000000}", 404, "Not found")
+	req(t, "PUT", "/v1/programs/pr1", "{\"delay\":-1}", 400, "Invalid duration")
+	req(t, "PUT", "/v1/programs/pr1", "{\"delay\":10000000000}", 200, "")
+	req(t, "GET", "/v1/programs/pr1", "", 200, "{\"delay\":10000000000, \"timeline\":[{\"element\":0,\"cycle\":0,\"devices\":[\"dev1\"],\"start\":0,\"duration\":60000000000},{\"element\":1,\"cycle\":0,\"devices\":[\"dev2\"],\"start\":70000000000,\"settle\":2000000000,\"duration\":60000000000}]}")
+	req(t, "PUT", "/v1/programs/pr1", "{\"delay\":0}", 200, "")
+	req(t, "GET", "/v1/programs/pr1", "", 200, "{\"delay\":0, \"timeline\":[{\"element\":0,\"cycle\":0,\"devices\":[\"dev1\"],\"start\":0,\"duration\":60000000000},{\"element\":1,\"cycle\":0,\"devices\":[\"dev2\"],\"start\":65000000000,\"settle\":2000000000,\"duration\":60000000000}]}")
+	req(t, "PUT", "/v1/programs/pr1", "{\"overlap\":3000000000}", 200, "")
+	req(t, "GET", "/v1/programs/pr1", "", 200, "{\"overlap\":3000000000, \"timeline\":[{\"element\":0,\"cycle\":0,\"devices\":[\"dev1\"],\"start\":0,\"duration\":60000000000},{\"element\":1,\"cycle\":0,\"devices\":[\"dev2\"],\"start\":57000000000,\"settle\":3000000000,\"overlap\":3000000000,\"duration\":60000000000}]}")
+
+	// cleanup
+	req(t, "DELETE", "/v1/programs/pr1", "", 200, "")
+	req(t, "DELETE", "/v1/devices/dev1", "", 200, "")
+	req(t, "DELETE", "/v1/devices/dev2", "", 200, "")
+}
+
+func TestApiEditProgramDevices(t *testing.T) {
+	req(t, "POST", "/v1/devices", "{\"name\":\"dev1\", \"pin\":1}", 200, "")
+	req(t, "POST", "/v1/devices", "{\"name\":\"dev2\", \"pin\":2}", 200, "")
+	req(t, "POST", "/v1/devices", "{\"name\":\"dev3\", \"pin\":3}", 200, "")
+	req(t, "POST", "/v1/programs", "{\"name\":\"pr1\"}", 200, "")
+	req(t, "POST", "/v1/programs/pr1/devices", "{\"device\":\"dev1\", \"duration\":\"5s\"}", 200, "")
+
+	// insert
+	req(t, "POST", "/v1/programs/pr1/devices/0", "{\"device\":\"dev2\", \"duration\":\"8s\"}", 200, "")
+	req(t, "POST", "/v1/programs/pr1/devices/3", "{\"device\":\"dev2\", \"duration\":\"8s\"}", 404, "Element index out of range")
+	req(t, "POST", "/v1/programs/pr1/devices/x", "{\"device\":\"dev2\", \"duration\":\"8s\"}", 404, "Element index out of range")
+	req(t, "POST", "/v1/programs/pr1/devices/0", "{\"device\":\"dev-whatever\", \"duration\":\"8s\"}", 404, "Not found")
+	req(t, "POST", "/v1/programs/pr-whatever/devices/0", "{\"device\":\"dev2\", \"duration\":\"8s\"}", 404, "Not found")
+	req(t, "GET", "/v1/programs/pr1", "", 200, "{\"devices\":[{\"device\":\"dev2\",\"duration\":8000000000},{\"device\":\"dev1\",\"duration\":5000000000}]}")
+
+	// update
+	req(t, "PUT", "/v1/programs/pr1/devices/1", "{\"device\":\"dev3\", \"duration\":\"3s\"}", 200, "")
+	req(t, "PUT", "/v1/programs/pr1/devices/2", "{\"device\":\"dev3\", \"duration\":\"3s\"}", 404, "Element index out of range")
+	req(t, "PUT", "/v1/programs/pr1/devices/1", "{\"device\":\"dev3\", \"volume\":-1}", 400, "Invalid volume")
+	req(t, "GET", "/v1/programs/pr1", "", 200, "{\"devices\":[{\"device\":\"dev2\",\"duration\":8000000000},{\"device\":\"dev3\",\"duration\":3000000000}]}")
+
+	// move
+	req(t, "POST", "/v1/programs/pr1/devices/1/move?to=0", "", 200, "")
+	req(t, "POST", "/v1/programs/pr1/devices/1/move?to=2", "", 404, "Element index out of range")
+	req(t, "POST", "/v1/programs/pr1/devices/1/move", "", 404, "Element index out of range")
+	req(t, "GET", "/v1/programs/pr1", "", 200, "{\"devices\":[{\"device\":\"dev3\",\"duration\":3000000000},{\"device\":\"dev2\",\"duration\":8000000000}]}")
+
+	// replace
+	req(t, "PUT", "/v1/programs/pr1/devices", "[{\"device\":\"dev1\", \"duration\":\"1s\"},{\"device\":\"dev-whatever\"}]", 404, "Not found")
+	req(t, "PUT", "/v1/programs/pr1/devices", "[{\"device\":\"dev1\", \"duration\":\"1s\"},{\"device\":\"dev2\", \"duration\":\"-1s\"}]", 400, "Invalid duration")
+	req(t, "GET", "/v1/programs/pr1", "", 200, "{\"devices\":[{\"device\":\"dev3\",\"duration\":3000000000},{\"device\":\"dev2\",\"duration\":8000000000}]}")
+	req(t, "PUT", "/v1/programs/pr1/devices", "[{\"device\":\"dev1\", \"duration\":\"1s\", \"parallel\":[\"dev2\"]}]", 200, "")
+	req(t, "GET", "/v1/programs/pr1", "", 200, "{\"devices\":[{\"device\":\"dev1\",\"parallel\":[\"dev2\"],\"duration\":1000000000}]}")
+
+	// cleanup
+	req(t, "DELETE", "/v1/programs/pr1", "", 200, "")
+	req(t, "DELETE", "/v1/devices/dev1", "", 200, "")
+	req(t, "DELETE", "/v1/devices/dev2", "", 200, "")
+	req(t, "DELETE", "/v1/devices/dev3", "", 200, "")
+}
+
+func TestApiParallelDevices(t *testing.T) {
+	req(t, "POST", "/v1/devices", "{\"name\":\"dev1\", \"pin\":1}", 200, "")
+	req(t, "POST", "/v1/devices", "{\"name\":\"dev2\", \"pin\":2}", 200, "")
+	req(t, "POST", "/v1/programs", "{\"name\":\"pr1\"}", 200, "")
+
+	req(t, "POST", "/v1/programs/pr1/devices", "{\"device\":\"dev1\", \"duration\":\"5s\", \"parallel\":[\"dev-whatever\"]}", 404, "Not found")
+	req(t, "POST", "/v1/programs/pr1/devices", "{\"device\":\"dev1\", \"duration\":\"5s\", \"parallel\":[\"dev2\"]}", 200, "")
+	req(t, "GET", "/v1/programs/pr1", "", 200, "{\"name\":\"pr1\", \"devices\":[{\"device\":\"dev1\",\"parallel\":[\"dev2\"],\"duration\":5000000000}]}")
+	req(t, "DELETE", "/v1/devices/dev2", "", 406, "Device is in use")
+
+	req(t, "POST", "/v1/programs/pr1/devices", "{\"device\":\"dev2\", \"duration\":\"10m\", \"cycle\":\"5m\", \"soak\":\"20m\"}", 200, "")
+	req(t, "GET", "/v1/programs/pr1", "", 200, "{\"timeline\":[{\"element\":0,\"cycle\":0,\"devices\":[\"dev1\",\"dev2\"],\"start\":0,\"duration\":5000000000},{\"element\":1,\"cycle\":0,\"devices\":[\"dev2\"],\"start\":6000000000,\"duration\":300000000000},{\"element\":1,\"cycle\":1,\"devices\":[\"dev2\"],\"start\":1506000000000,\"duration\":300000000000}]}")
+
+	// cleanup
+	req(t, "DELETE", "/v1/programs/pr1", "", 200, "")
+	req(t, "DELETE", "/v1/devices/dev1", "", 200, "")
+	req(t, "DELETE", "/v1/devices/dev2", "", 200, "")
+}
+
+func TestApiSensors(t *testing.T) {
+	req(t, "GET", "/v1/sensors", "", 200, "{}")
+	req(t, "POST", "/v1/sensors", "{\"name\":\"rain\", \"type\":\"unknown\", \"pin\":5}", 400, "Invalid sensor")
+	req(t, "POST", "/v1/sensors", "{\"name\":\"rain\", \"type\":\"rain\", \"pin\":5, \"pull\":\"up\"}", 200, "")
+	req(t, "POST", "/v1/sensors", "{\"name\":\"rain\", \"type\":\"rain\", \"pin\":5}", 406, "Already exists")
+	req(t, "GET", "/v1/sensors/rain", "", 200, "{\"name\":\"rain\", \"type\":\"rain\", \"pin\":5, \"pull\":\"up\", \"active\":false}")
+	req(t, "GET", "/v1/sensors", "", 200, "{\"rain\":{\"name\":\"rain\", \"active\":false}}")
+	req(t, "DELETE", "/v1/sensors/rain", "", 200, "")
+	req(t, "GET", "/v1/sensors/rain", "", 404, "Not found")
+}
+
+func TestApiUsage(t *testing.T) {
+	req(t, "POST", "/v1/sensors", "{\"name\":\"flow\", \"type\":\"flow\", \"pin\":6}", 400, "Invalid sensor")
+	req(t, "POST", "/v1/sensors", "{\"name\":\"flow\", \"type\":\"flow\", \"pin\":6, \"k-factor\":7.5}", 200, "")
+	req(t, "GET", "/v1/sensors/flow", "", 200, "{\"name\":\"flow\", \"type\":\"flow\", \"k-factor\":7.5}")
+	req(t, "DELETE", "/v1/sensors/flow", "", 200, "")
+
+	req(t, "GET", "/v1/usage?from=yesterday", "", 400, "Invalid day")
+	req(t, "GET", "/v1/usage?from=2026-06-01&to=2026-06-30", "", 200, "{\"from\":\"2026-06-01\", \"to\":\"2026-06-30\", \"devices\":{}, \"programs\":{}}")
+}
+
+func TestApiProgramVolume(t *testing.T) {
+	req(t, "POST", "/v1/devices", "{\"name\":\"dev1\", \"pin\":1, \"flow\":20}", 200, "")
+	req(t, "POST", "/v1/programs", "{\"name\":\"pr1\"}", 200, "")
+	req(t, "POST", "/v1/programs/pr1/devices", "{\"device\":\"dev1\", \"volume\":-5}", 400, "Invalid volume")
+	req(t, "POST", "/v1/programs/pr1/devices", "{\"device\":\"dev1\", \"volume\":200}", 200, "")
+	req(t, "POST", "/v1/programs/pr1/devices", "{\"device\":\"dev1\", \"duration\":\"10m\"}", 200, "")
+	req(t, "GET", "/v1/programs/pr1", "", 200,
+		"{\"devices\":[{\"device\":\"dev1\",\"duration\":0,\"volume\":200},{\"device\":\"dev1\",\"duration\":600000000000}], "+
+			"\"timeline\":[{\"element\":0,\"cycle\":0,\"devices\":[\"dev1\"],\"start\":0,\"duration\":600000000000,\"volume\":200},"+
+			"{\"element\":1,\"cycle\":0,\"devices\":[\"dev1\"],\"start\":601000000000,\"duration\":600000000000}]}")
+	req(t, "DELETE", "/v1/programs/pr1", "", 200, "")
+	req(t, "DELETE", "/v1/devices/dev1", "", 200, "")
+}
+
+func TestApiEvents(t *testing.T) {
+	req(t, "GET", "/v1/events", "", 200, "[]")
+	req(t, "GET", "/v1/events?since=yesterday", "", 400, "Invalid time")
+	req(t, "GET", "/v1/events?since=2026-06-01T00:00:00Z", "", 200, "[]")
+	req(t, "DELETE", "/v1/events", "", 200, "")
+
+	req(t, "POST", "/v1/devices", "{\"name\":\"dev1\", \"pin\":1, \"flow\":-2}", 400, "Invalid flow")
+	req(t, "POST", "/v1/devices", "{\"name\":\"dev1\", \"pin\":1, \"flow\":12.5}", 200, "")
+	req(t, "GET", "/v1/devices/dev1", "", 200, "{\"name\":\"dev1\", \"flow\":12.5}")
+	req(t, "DELETE", "/v1/devices/dev1", "", 200, "")
+}
+
+func TestApiBlackouts(t *testing.T) {
+	req(t, "GET", "/v1/blackouts", "", 200, "[]")
+	req(t, "PUT", "/v1/blackouts", "[{\"name\":\"day\", \"from\":\"10am\", \"to\":\"18:00\"}]", 400, "Invalid blackout")
+	req(t, "PUT", "/v1/blackouts", "[{\"name\":\"day\", \"from\":\"10:00\", \"to\":\"18:00\"}]", 200, "")
+	req(t, "GET", "/v1/blackouts", "", 200, "[{\"name\":\"day\",\"from\":\"10:00\",\"to\":\"18:00\"}]")
+	req(t, "PUT", "/v1/settings", "{\"blackout-policy\":\"later\"}", 400, "Invalid settings")
+	req(t, "PUT", "/v1/settings", "{\"blackout-policy\":\"shift\"}", 200, "")
+	req(t, "GET", "/v1/settings", "", 200, "{\"blackout-policy\":\"shift\"}")
+	req(t, "PUT", "/v1/settings", "{}", 200, "")
+
+	req(t, "POST", "/v1/schedules", "{\"name\":\"sc1\", \"spec\":\"0 6 * * *\", \"active-from\":\"Apr 15\"}", 400, "Invalid active range")
+	req(t, "POST", "/v1/schedules", "{\"name\":\"sc1\", \"spec\":\"0 6 * * *\", \"active-from\":\"04-15\", \"active-to\":\"10-15\"}", 200, "")
+	req(t, "GET", "/v1/schedules/sc1", "", 200, "{\"active-from\":\"04-15\", \"active-to\":\"10-15\"}")
+	req(t, "DELETE", "/v1/schedules/sc1", "", 200, "")
+}
+
+func TestApiCalendar(t *testing.T) {
+	req(t, "GET", "/v1/calendar", "", 200, "[]")
+	req(t, "GET", "/v1/calendar?from=tomorrow", "", 400, "Invalid day")
+
+	req(t, "POST", "/v1/devices", "{\"name\":\"dev1\", \"pin\":1}", 200, "")
+	req(t, "POST", "/v1/programs", "{\"name\":\"pr1\"}", 200, "")
+	req(t, "POST", "/v1/programs/pr1/devices", "{\"device\":\"dev1\", \"duration\":\"10m\"}", 200, "")
+	req(t, "POST", "/v1/schedules", "{\"name\":\"sc1\", \"spec\":\"0 6 * * *\", \"program\":\"pr1\", \"enabled\":true}", 200, "")
+	req(t, "GET", "/v1/calendar?from=2026-06-01&to=2026-06-01", "", 200,
+		"[{\"schedule\":\"sc1\",\"program\":\"pr1\",\"start\":\""+time.Date(2026, 6, 1, 6, 0, 0, 0, time.Local).Format(time.RFC3339)+"\","+
+			"\"end\":\""+time.Date(2026, 6, 1, 6, 10, 0, 0, time.Local).Format(time.RFC3339)+"\","+
+			"\"zones\":[{\"device\":\"dev1\",\"on\":\""+time.Date(2026, 6, 1, 6, 0, 0, 0, time.Local).Format(time.RFC3339)+"\","+
+			"\"off\":\""+time.Date(2026, 6, 1, 6, 10, 0, 0, time.Local).Format(time.RFC3339)+"\"}]}]")
+	req(t, "DELETE", "/v1/schedules/sc1", "", 200, "")
+	req(t, "DELETE", "/v1/programs/pr1", "", 200, "")
+	req(t, "DELETE", "/v1/devices/dev1", "", 200, "")
+}
+
+func TestApiQueue(t *testing.T) {
+	req(t, "GET", "/v1/queue", "", 200, "[]")
+	req(t, "DELETE", "/v1/queue/0", "", 404, "Queue index out of range")
+	req(t, "PUT", "/v1/settings", "{\"overlap-policy\":\"later\"}", 400, "Invalid settings")
+	req(t, "PUT", "/v1/settings", "{\"overlap-policy\":\"queue\", \"serialize\":true}", 200, "")
+	req(t, "GET", "/v1/settings", "", 200, "{\"overlap-policy\":\"queue\", \"serialize\":true}")
+
+	req(t, "POST", "/v1/devices", "{\"name\":\"dev1\", \"pin\":1}", 200, "")
+	req(t, "POST", "/v1/programs", "{\"name\":\"pr1\"}", 200, "")
+	req(t, "POST", "/v1/programs", "{\"name\":\"pr2\"}", 200, "")
+	req(t, "POST", "/v1/programs/pr1/devices", "{\"device\":\"dev1\", \"duration\":\"5s\"}", 200, "")
+	req(t, "POST", "/v1/programs/pr2/devices", "{\"device\":\"dev1\", \"duration\":\"5s\"}", 200, "")
+
+	req(t, "POST", "/v1/programs/pr1/start", "", 200, "{\"result\":\"started\"}")
+	req(t, "POST", "/v1/programs/pr2/start", "", 200, "{\"result\":\"queued\"}")
+	req(t, "POST", "/v1/programs/pr1/start", "", 200, "{\"result\":\"queued\"}")
+	req(t, "DELETE", "/v1/queue/1", "", 200, "")
+	req(t, "GET", "/v1/queue", "", 200, "\"program\":\"pr2\",\"source\":\"api\"")
+	req(t, "DELETE", "/v1/queue", "", 200, "")
+	req(t, "GET", "/v1/queue", "", 200, "[]")
+
+	req(t, "PUT", "/v1/settings", "{\"serialize\":true}", 200, "")
+	req(t, "POST", "/v1/programs/pr2/start", "", 406, "Another program is running")
+	req(t, "POST", "/v1/programs/pr1/start", "", 406, "Program is already running")
+
+	// cleanup
+	req(t, "POST", "/v1/programs/pr1/stop", "", 200, "")
+	req(t, "PUT", "/v1/settings", "{}", 200, "")
+	req(t, "DELETE", "/v1/programs/pr1", "", 200, "")
+	req(t, "DELETE", "/v1/programs/pr2", "", 200, "")
+	req(t, "DELETE", "/v1/devices/dev1", "", 200, "")
+}
+
+func TestApiSettings(t *testing.T) {
+	req(t, "GET", "/v1/settings", "", 200, "{}")
+	req(t, "PUT", "/v1/settings", "{\"max-open-valves\":-1}", 400, "Invalid settings")
+	req(t, "PUT", "/v1/settings", "{\"max-open-valves\":2}", 200, "")
+	req(t, "GET", "/v1/settings", "", 200, "{\"max-open-valves\":2}")
+	req(t, "PUT", "/v1/settings", "{}", 200, "")
+}
+
+func TestApiRainDelay(t *testing.T) {
+	req(t, "GET", "/v1/raindelay", "", 200, "{}")
+	req(t, "PUT", "/v1/raindelay", "{\"delay\":\"invalid\"}", 400, "Invalid duration")
+	req(t, "PUT", "/v1/raindelay", "{\"until\":\"2000-01-01T00:00:00Z\"}", 200, "")
+	req(t, "GET", "/v1/raindelay", "", 200, "{}")
+	req(t, "PUT", "/v1/raindelay", "{\"until\":\"2100-01-01T00:00:00Z\"}", 200, "")
+	req(t, "GET", "/v1/raindelay", "", 200, "{\"until\":\"2100-01-01T00:00:00Z\"}")
+	req(t, "PUT", "/v1/raindelay", "{\"delay\":\"48h\"}", 200, "")
+	req(t, "DELETE", "/v1/raindelay", "", 200, "")
+	req(t, "GET", "/v1/raindelay", "", 200, "{}")
+}
+
+func TestApiAdjustment(t *testing.T) {
+	req(t, "GET", "/v1/adjustment", "", 200, "{}")
+	req(t, "PUT", "/v1/adjustment", "{\"foo\":60}", 400, "Invalid seasonal adjustment")
+	req(t, "PUT", "/v1/adjustment", "{\"apr\":60, \"jul\":120}", 200, "")
+	req(t, "GET", "/v1/adjustment", "", 200, "{\"apr\":60, \"jul\":120}")
+	req(t, "GET", "/v1/settings", "", 200, "{\"adjustment\":{\"apr\":60, \"jul\":120}}")
+
+	req(t, "POST", "/v1/programs", "{\"name\":\"pr1\"}", 200, "")
+	req(t, "GET", "/v1/programs/pr1/adjustment", "", 200, "{}")
+	req(t, "PUT", "/v1/programs/pr1/adjustment", "{\"jul\":-5}", 400, "Invalid seasonal adjustment")
+	req(t, "PUT", "/v1/programs/pr1/adjustment", "{\"jul\":150}", 200, "")
+	req(t, "GET", "/v1/programs/pr1/adjustment", "", 200, "{\"jul\":150}")
+	req(t, "GET", "/v1/programs/pr1", "", 200, "{\"adjustment\":{\"jul\":150}}")
+	req(t, "PUT", "/v1/programs/pr-whatever/adjustment", "{}", 404, "Not found")
+
+	// cleanup
+	req(t, "DELETE", "/v1/programs/pr1", "", 200, "")
+	req(t, "PUT", "/v1/adjustment", "{}", 200, "")
+}
+
 func TestApiDelDeviceInUse(t *testing.T) {
 	req(t, "POST", "/v1/devices", "{\"name\":\"dev1\", \"pin\":1}", 200, "")
 	req(t, "POST", "/v1/programs", "{\"name\":\"pr1\"}", 200, "")
@@ -185,6 +468,38 @@ func TestApiStartStopProgram(t *testing.T) {
 	req(t, "DELETE", "/v1/devices/dev2", "", 200, "")
 }
 
+func TestApiPauseResumeProgram(t *testing.T) {
+	req(t, "POST", "/v1/devices", "{\"name\":\"dev1\", \"pin\":1}", 200, "")
+	req(t, "POST", "/v1/programs", "{\"name\":\"pr1\"}", 200, "")
+	req(t, "POST", "/v1/programs/pr1/devices", "{\"device\":\"dev1\", \"duration\":\"5s\"}", 200, "")
+
+	req(t, "POST", "/v1/programs/pr-unknown/pause", "", 404, "Not found")
+	req(t, "POST", "/v1/programs/pr1/pause", "", 406, "Program is not running")
+	req(t, "POST", "/v1/programs/pr1/resume", "", 406, "Program is not paused")
+
+	req(t, "POST", "/v1/programs/pr1/start", "", 200, "")
+	time.Sleep(100 * time.Millisecond)
+	req(t, "POST", "/v1/programs/pr1/pause", "", 200, "")
+	req(t, "GET", "/v1/devices/dev1", "", 200, "{\"name\":\"dev1\", \"on\":false}")
+	req(t, "GET", "/v1/programs/pr1", "", 200, "{\"paused\":true, \"progress\":{\"step\":0}, \"status\":{\"state\":\"paused\",\"step\":0}}")
+	req(t, "POST", "/v1/programs/pr1/resume", "", 200, "{\"result\":\"started\"}")
+	time.Sleep(100 * time.Millisecond)
+	req(t, "GET", "/v1/devices/dev1", "", 200, "{\"name\":\"dev1\", \"on\":true}")
+	req(t, "GET", "/v1/programs/pr1", "", 200, "{\"status\":{\"state\":\"running\",\"step\":0,\"element\":0,\"devices\":[\"dev1\"],\"source\":\"api\"}}")
+
+	// skipping the only step finishes the program
+	req(t, "POST", "/v1/programs/pr-unknown/next", "", 404, "Not found")
+	req(t, "POST", "/v1/programs/pr1/prev", "", 200, "")
+	req(t, "POST", "/v1/programs/pr1/next", "", 200, "")
+	time.Sleep(100 * time.Millisecond)
+	req(t, "GET", "/v1/devices/dev1", "", 200, "{\"name\":\"dev1\", \"on\":false}")
+
+	// cleanup
+	req(t, "POST", "/v1/programs/pr1/stop", "", 200, "")
+	req(t, "DELETE", "/v1/programs/pr1", "", 200, "")
+	req(t, "DELETE", "/v1/devices/dev1", "", 200, "")
+}
+
 func TestApiAddDelSchedule(t *testing.T) {
 	req(t, "POST", "/v1/schedules", "{\"name\":\"sc1\", \"spec\":\"* * * * *\"}", 200, "")
 	req(t, "POST", "/v1/schedules", "{\"name\":\"sc1\", \"spec\":\"* * * * *\"}", 406, "Already exists")
@@ -204,6 +519,35 @@ func TestApiAddDelSchedule(t *testing.T) {
 
 	req(t, "DELETE", "/v1/schedules/sc1", "", 200, "")
 	req(t, "GET", "/v1/schedules/sc1", "", 404, "Not found")
+
+	req(t, "POST", "/v1/schedules", "{\"name\":\"sc2\", \"spec\":\"@sunset soon\"}", 400, "Invalid schedule spec")
+	req(t, "POST", "/v1/schedules", "{\"name\":\"sc2\", \"spec\":\"@sunset -15m\"}", 200, "")
+	req(t, "GET", "/v1/schedules/sc2", "", 200, "{\"name\":\"sc2\", \"spec\":\"@sunset -15m\"}")
+	req(t, "DELETE", "/v1/schedules/sc2", "", 200, "")
+
+	req(t, "POST", "/v1/schedules", "{\"name\":\"sc3\", \"spec\":\"@odd 06:30 skip-monday\"}", 400, "Invalid schedule spec")
+	req(t, "POST", "/v1/schedules", "{\"name\":\"sc3\", \"spec\":\"@odd 06:30 skip-31st\"}", 200, "")
+	req(t, "PUT", "/v1/schedules/sc3", "{\"spec\":\"@interval 3 2026-10-01 06:30\"}", 200, "")
+	req(t, "GET", "/v1/schedules/sc3", "", 200, "{\"name\":\"sc3\", \"spec\":\"@interval 3 2026-10-01 06:30\"}")
+	req(t, "DELETE", "/v1/schedules/sc3", "", 200, "")
+
+	req(t, "POST", "/v1/schedules", "{\"name\":\"sc4\", \"spec\":\"@once 2100-01-01 06:30 keep\"}", 400, "Invalid schedule spec")
+	req(t, "POST", "/v1/schedules", "{\"name\":\"sc4\", \"spec\":\"@once 2000-01-01 06:30 delete\", \"program\":\"pr1\", \"enabled\":true}", 400, "Time is in the past")
+	req(t, "POST", "/v1/schedules", "{\"name\":\"sc4\", \"spec\":\"@once 2100-01-01 06:30 delete\", \"program\":\"pr1\", \"enabled\":true}", 200, "")
+	req(t, "GET", "/v1/schedules/sc4", "", 200, "{\"enabled\":true, \"next\":\""+time.Date(2100, 1, 1, 6, 30, 0, 0, time.Local).Format(time.RFC3339)+"\"}")
+	req(t, "DELETE", "/v1/schedules/sc4", "", 200, "")
+	req(t, "DELETE", "/v1/programs/pr1", "", 200, "")
+
+	req(t, "POST", "/v1/schedules", "{\"name\":\"sc5\", \"spec\":\"4 4 4 4 *\"}", 200, "")
+	req(t, "POST", "/v1/schedules/sc-unknown/skip", "", 404, "Not found")
+	req(t, "POST", "/v1/schedules/sc5/skip?count=-1", "", 400, "Invalid number of runs to skip")
+	req(t, "POST", "/v1/schedules/sc5/skip?count=3", "", 200, "")
+	req(t, "GET", "/v1/schedules/sc5", "", 200, "{\"skip-next\":3}")
+	req(t, "POST", "/v1/schedules/sc5/pause?until=tomorrow", "", 400, "Invalid time")
+	req(t, "POST", "/v1/schedules/sc5/pause?until=2100-01-01T00:00:00Z", "", 200, "")
+	req(t, "GET", "/v1/schedules/sc5", "", 200, "{\"paused-until\":\"2100-01-01T00:00:00Z\"}")
+	req(t, "DELETE", "/v1/schedules/sc5/pause", "", 200, "")
+	req(t, "DELETE", "/v1/schedules/sc5", "", 200, "")
 }
 
 // func TestApiBadRequests(t *testing.T) {
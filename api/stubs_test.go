@@ -1,6 +1,10 @@
 package api_test
 
-import "github.com/peter-vaczi/sprinkler/gpio"
+import (
+	"sync"
+
+	"github.com/peter-vaczi/sprinkler/gpio"
+)
 
 type GpioStub struct {
 	pins map[int]*PinStub
@@ -20,8 +24,26 @@ type PinStub struct {
 	pin    int
 	output bool
 	high   bool
+	pull   gpio.Pull
+	m      sync.Mutex
 }
 
-func (p *PinStub) Output() { p.output = true }
-func (p *PinStub) High()   { p.high = true }
-func (p *PinStub) Low()    { p.high = false }
+func (p *PinStub) Output()             { p.output = true }
+func (p *PinStub) High()               { p.setHigh(true) }
+func (p *PinStub) Low()                { p.setHigh(false) }
+func (p *PinStub) Input()              { p.output = false }
+func (p *PinStub) Pull(pull gpio.Pull) { p.pull = pull }
+
+func (p *PinStub) Read() bool {
+	p.m.Lock()
+	defer p.m.Unlock()
+
+	return p.high
+}
+
+func (p *PinStub) setHigh(high bool) {
+	p.m.Lock()
+	defer p.m.Unlock()
+
+	p.high = high
+}
@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+var adjustmentFlagProgram string
+
+// adjustmentCmd represents the adjustment command
+var adjustmentCmd = &cobra.Command{
+	Use:   "adjustment",
+	Short: "Handle the seasonal adjustment of the watering durations",
+	Long: `Handle the seasonal adjustment of the watering durations
+
+The durations of the programs are scaled by a percentage set for each month.
+The table of a program overrides the controller wide table for the months it
+contains.`,
+}
+
+// adjustmentURL returns the url of the controller wide or the program's
+// adjustment table
+func adjustmentURL() string {
+	if 0 < len(adjustmentFlagProgram) {
+		return fmt.Sprintf("%s/v1/programs/%s/adjustment", daemonSocket, adjustmentFlagProgram)
+	}
+	return daemonSocket + "/v1/adjustment"
+}
+
+func init() {
+	adjustmentCmd.PersistentFlags().StringVar(&adjustmentFlagProgram, "program", "", "handle the adjustment table of this program")
+	RootCmd.AddCommand(adjustmentCmd)
+}
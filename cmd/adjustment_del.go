@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	"log"
+	"os"
+	"strings"
+
+	"github.com/peter-vaczi/sprinkler/core"
+	"github.com/peter-vaczi/sprinkler/utils"
+	"github.com/spf13/cobra"
+)
+
+// adjustmentDelCmd represents the adjustment del command
+var adjustmentDelCmd = &cobra.Command{
+	Use:   "del <month>",
+	Short: "Remove the watering scale of a month",
+	Long:  `Remove the watering scale of a month`,
+	Run: func(cmd *cobra.Command, args []string) {
+
+		if len(args) != 1 {
+			cmd.Usage()
+			os.Exit(-1)
+		}
+
+		var adj core.Adjustment
+
+		err := utils.GetRequest(adjustmentURL(), &adj)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		delete(adj, strings.ToLower(args[0]))
+
+		err = utils.PutRequest(adjustmentURL(), &adj)
+		if err != nil {
+			log.Fatal(err)
+		}
+	},
+}
+
+func init() {
+	adjustmentCmd.AddCommand(adjustmentDelCmd)
+}
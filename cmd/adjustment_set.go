@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/peter-vaczi/sprinkler/core"
+	"github.com/peter-vaczi/sprinkler/utils"
+	"github.com/spf13/cobra"
+)
+
+// adjustmentSetCmd represents the adjustment set command
+var adjustmentSetCmd = &cobra.Command{
+	Use:   "set <month> <percent>",
+	Short: "Set the watering scale of a month",
+	Long:  `Set the watering scale of a month, e.g.: set apr 60`,
+	Run: func(cmd *cobra.Command, args []string) {
+
+		if len(args) != 2 {
+			cmd.Usage()
+			os.Exit(-1)
+		}
+
+		pct, err := strconv.Atoi(strings.TrimSuffix(args[1], "%"))
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		var adj core.Adjustment
+
+		err = utils.GetRequest(adjustmentURL(), &adj)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		if adj == nil {
+			adj = core.Adjustment{}
+		}
+		adj[strings.ToLower(args[0])] = pct
+
+		err = utils.PutRequest(adjustmentURL(), &adj)
+		if err != nil {
+			log.Fatal(err)
+		}
+	},
+}
+
+func init() {
+	adjustmentCmd.AddCommand(adjustmentSetCmd)
+}
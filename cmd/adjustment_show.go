@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/peter-vaczi/sprinkler/core"
+	"github.com/peter-vaczi/sprinkler/utils"
+)
+
+var adjustmentShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Show the seasonal adjustment table",
+	Long:  `Show the seasonal adjustment table`,
+	Run: func(cmd *cobra.Command, args []string) {
+
+		var adj core.Adjustment
+
+		err := utils.GetRequest(adjustmentURL(), &adj)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		printAdjustment(adj)
+	},
+}
+
+func printAdjustment(adj core.Adjustment) {
+	w := new(tabwriter.Writer)
+
+	w.Init(os.Stdout, 5, 0, 1, ' ', 0)
+	fmt.Fprintln(w, "MONTH\tSCALE\t")
+
+	for m := time.January; m <= time.December; m++ {
+		scale := "-"
+		if pct, found := adj[core.MonthKey(m)]; found {
+			scale = fmt.Sprintf("%d%%", pct)
+		}
+		fmt.Fprintf(w, "%s\t%s\t\n", core.MonthKey(m), scale)
+	}
+
+	w.Flush()
+}
+
+func init() {
+	adjustmentCmd.AddCommand(adjustmentShowCmd)
+}
@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/peter-vaczi/sprinkler/core"
+	"github.com/peter-vaczi/sprinkler/utils"
+)
+
+// blackoutCmd represents the blackout command
+var blackoutCmd = &cobra.Command{
+	Use:   "blackout",
+	Short: "Handle the time windows the schedules must not water in",
+	Long: `Handle the time windows the schedules must not water in
+
+A run of a schedule inside a blackout window is skipped or shifted to the end
+of the window depending on the blackout policy of the settings.`,
+}
+
+// getBlackouts returns the blackout windows of the controller
+func getBlackouts() []*core.Blackout {
+	var blackouts []*core.Blackout
+
+	err := utils.GetRequest(daemonSocket+"/v1/blackouts", &blackouts)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return blackouts
+}
+
+func printBlackouts(blackouts []*core.Blackout) {
+	w := new(tabwriter.Writer)
+
+	w.Init(os.Stdout, 5, 0, 1, ' ', 0)
+	fmt.Fprintln(w, "NAME\tDAYS\tFROM\tTO\t")
+
+	for _, b := range blackouts {
+		days := "every day"
+		if len(b.Days) != 0 {
+			days = strings.Join(b.Days, ",")
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t\n", b.Name, days, b.From, b.To)
+	}
+
+	w.Flush()
+}
+
+func init() {
+	RootCmd.AddCommand(blackoutCmd)
+}
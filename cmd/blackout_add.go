@@ -0,0 +1,41 @@
+package cmd
+
+import (
+	"log"
+	"os"
+
+	"github.com/peter-vaczi/sprinkler/core"
+	"github.com/peter-vaczi/sprinkler/utils"
+	"github.com/spf13/cobra"
+)
+
+var blackoutAddFlagDays []string
+var blackoutAddFlagFrom string
+var blackoutAddFlagTo string
+
+// blackoutAddCmd represents the blackout add command
+var blackoutAddCmd = &cobra.Command{
+	Use:   "add <name> --from <HH:MM> --to <HH:MM> [flags]",
+	Short: "Add a blackout window",
+	Long:  `Add a blackout window, e.g.: add party --days sun --from 08:00 --to 13:00`,
+	Run: func(cmd *cobra.Command, args []string) {
+
+		if len(args) != 1 {
+			cmd.Usage()
+			os.Exit(-1)
+		}
+
+		blackouts := append(getBlackouts(), &core.Blackout{Name: args[0], Days: blackoutAddFlagDays, From: blackoutAddFlagFrom, To: blackoutAddFlagTo})
+		err := utils.PutRequest(daemonSocket+"/v1/blackouts", &blackouts)
+		if err != nil {
+			log.Fatal(err)
+		}
+	},
+}
+
+func init() {
+	blackoutCmd.AddCommand(blackoutAddCmd)
+	blackoutAddCmd.Flags().StringSliceVar(&blackoutAddFlagDays, "days", nil, "weekdays of the window, e.g.: sat,sun, every day by default")
+	blackoutAddCmd.Flags().StringVar(&blackoutAddFlagFrom, "from", "", "start of the window, e.g.: 10:00")
+	blackoutAddCmd.Flags().StringVar(&blackoutAddFlagTo, "to", "", "end of the window, e.g.: 18:00")
+}
@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"log"
+	"os"
+
+	"github.com/peter-vaczi/sprinkler/core"
+	"github.com/peter-vaczi/sprinkler/utils"
+	"github.com/spf13/cobra"
+)
+
+// blackoutDelCmd represents the blackout del command
+var blackoutDelCmd = &cobra.Command{
+	Use:   "del <name>",
+	Short: "Delete a blackout window",
+	Long:  `Delete a blackout window`,
+	Run: func(cmd *cobra.Command, args []string) {
+
+		if len(args) != 1 {
+			cmd.Usage()
+			os.Exit(-1)
+		}
+
+		blackouts := []*core.Blackout{}
+		found := false
+		for _, b := range getBlackouts() {
+			if b.Name == args[0] {
+				found = true
+				continue
+			}
+			blackouts = append(blackouts, b)
+		}
+		if !found {
+			log.Fatalf("blackout %s not found", args[0])
+		}
+
+		err := utils.PutRequest(daemonSocket+"/v1/blackouts", &blackouts)
+		if err != nil {
+			log.Fatal(err)
+		}
+	},
+}
+
+func init() {
+	blackoutCmd.AddCommand(blackoutDelCmd)
+}
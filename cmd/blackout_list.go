@@ -0,0 +1,18 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var blackoutListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the blackout windows",
+	Long:  `List the blackout windows`,
+	Run: func(cmd *cobra.Command, args []string) {
+		printBlackouts(getBlackouts())
+	},
+}
+
+func init() {
+	blackoutCmd.AddCommand(blackoutListCmd)
+}
@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/peter-vaczi/sprinkler/core"
+	"github.com/peter-vaczi/sprinkler/utils"
+)
+
+var calendarFlagDays int
+
+// calendarCmd represents the calendar command
+var calendarCmd = &cobra.Command{
+	Use:   "calendar",
+	Short: "Show the upcoming program runs",
+	Long: `Show the upcoming program runs of the enabled schedules with the on and off
+times of their zones, e.g.: calendar --days 7`,
+	Run: func(cmd *cobra.Command, args []string) {
+
+		if len(args) != 0 || calendarFlagDays < 1 {
+			cmd.Usage()
+			os.Exit(-1)
+		}
+
+		query := url.Values{}
+		query.Set("to", time.Now().AddDate(0, 0, calendarFlagDays-1).Format("2006-01-02"))
+
+		var entries []*core.CalendarEntry
+		err := utils.GetRequest(daemonSocket+"/v1/calendar?"+query.Encode(), &entries)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		printCalendar(entries)
+	},
+}
+
+func printCalendar(entries []*core.CalendarEntry) {
+	w := new(tabwriter.Writer)
+
+	w.Init(os.Stdout, 5, 0, 1, ' ', 0)
+	fmt.Fprintln(w, "START\tEND\tSCHEDULE\tPROGRAM\tNOTE\t")
+
+	for _, e := range entries {
+		note := ""
+		if e.Skipped != "" {
+			note = "skipped: " + e.Skipped
+		} else if len(e.Overlaps) != 0 {
+			note = "overlaps " + strings.Join(e.Overlaps, ",")
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t\n", e.Start.Local().Format("2006-01-02 15:04"), e.End.Local().Format("15:04:05"), e.Schedule, e.Program, note)
+		for _, z := range e.Zones {
+			fmt.Fprintf(w, "  %s\t%s\t%s\t\t\t\n", z.On.Local().Format("15:04:05"), z.Off.Local().Format("15:04:05"), z.Device)
+		}
+	}
+
+	w.Flush()
+}
+
+func init() {
+	calendarCmd.Flags().IntVar(&calendarFlagDays, "days", 7, "number of days to show, starting today")
+	RootCmd.AddCommand(calendarCmd)
+}
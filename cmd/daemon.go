@@ -47,8 +47,11 @@ func runDaemon() {
 		api := api.New(daemonSocket, data)
 		go api.Run()
 		waitForSignal()
-		data.Schedules.DisableAll()
-		data.Programs.StopAll()
+		data.Schedules.StopAll()
+		data.Queue.Clear()
+		data.Programs.SuspendAll()
+		data.Devices.StopAll()
+		data.Sensors.StopAll()
 		data.StoreState()
 	}
 }
@@ -3,6 +3,7 @@ package cmd
 import (
 	"log"
 	"os"
+	"time"
 
 	"github.com/peter-vaczi/sprinkler/core"
 	"github.com/peter-vaczi/sprinkler/utils"
@@ -12,6 +13,13 @@ import (
 var addFlagOn bool
 var addFlagSwitchOnLow bool
 var addFlagPin int
+var addFlagRole string
+var addFlagLead time.Duration
+var addFlagLag time.Duration
+var addFlagMaxOn time.Duration
+var addFlagFlow float64
+var addFlagOpenSettle time.Duration
+var addFlagCloseSettle time.Duration
 
 // deviceAddCmd represents the add command
 var deviceAddCmd = &cobra.Command{
@@ -25,7 +33,9 @@ var deviceAddCmd = &cobra.Command{
 			os.Exit(-1)
 		}
 
-		dev := core.Device{Name: args[0], On: addFlagOn, Pin: addFlagPin, SwitchOnLow: addFlagSwitchOnLow}
+		dev := core.Device{Name: args[0], On: addFlagOn, Pin: addFlagPin, SwitchOnLow: addFlagSwitchOnLow,
+			Role: addFlagRole, Lead: addFlagLead, Lag: addFlagLag, MaxOn: addFlagMaxOn, Flow: addFlagFlow,
+			OpenSettle: addFlagOpenSettle, CloseSettle: addFlagCloseSettle}
 		err := utils.PostRequest(daemonSocket+"/v1/devices", &dev)
 		if err != nil {
 			log.Fatal(err)
@@ -38,4 +48,11 @@ func init() {
 	deviceAddCmd.PersistentFlags().IntVar(&addFlagPin, "pin", 0, "GPIO pin associated with this device")
 	deviceAddCmd.PersistentFlags().BoolVar(&addFlagOn, "on", false, "set the device on")
 	deviceAddCmd.PersistentFlags().BoolVar(&addFlagSwitchOnLow, "switch-on-low", false, "device is considered 'on' when it's output pin is low")
+	deviceAddCmd.PersistentFlags().StringVar(&addFlagRole, "role", "zone", "role of the device: zone, master or pump")
+	deviceAddCmd.PersistentFlags().DurationVar(&addFlagLead, "lead", 0, "master only: switch on this long before the first zone opens")
+	deviceAddCmd.PersistentFlags().DurationVar(&addFlagLag, "lag", 0, "master only: switch off this long after the last zone closes")
+	deviceAddCmd.PersistentFlags().DurationVar(&addFlagMaxOn, "max-on", 0, "switch the device off if it is on for longer than this, 0 means no limit")
+	deviceAddCmd.PersistentFlags().Float64Var(&addFlagFlow, "flow", 0, "expected flow in liters per minute, 0 means learn it from the flow meter")
+	deviceAddCmd.PersistentFlags().DurationVar(&addFlagOpenSettle, "open-settle", 0, "time the valve needs to open fully, not counted as watering time")
+	deviceAddCmd.PersistentFlags().DurationVar(&addFlagCloseSettle, "close-settle", 0, "time the valve needs to close fully before the next one opens")
 }
@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/peter-vaczi/sprinkler/utils"
+	"github.com/spf13/cobra"
+)
+
+var deviceRunFlagFor string
+
+// deviceRunCmd represents the device run command
+var deviceRunCmd = &cobra.Command{
+	Use:   "run <name> [flags]",
+	Short: "Open a device for a fixed time",
+	Long: `Open a device for a fixed time, then close it automatically.
+The device waits for a free valve and switches the master devices like the programs do,
+a zone watered by a program, or any zone while serialized programs run, is refused.`,
+	Run: func(cmd *cobra.Command, args []string) {
+
+		if len(args) != 1 {
+			cmd.Usage()
+			os.Exit(-1)
+		}
+
+		data := make(map[string]string)
+		data["duration"] = deviceRunFlagFor
+		err := utils.PostRequest(
+			fmt.Sprintf("%s/v1/devices/%s/run", daemonSocket, args[0]),
+			&data)
+		if err != nil {
+			log.Fatal(err)
+		}
+	},
+}
+
+func init() {
+	deviceCmd.AddCommand(deviceRunCmd)
+	deviceRunCmd.Flags().StringVar(&deviceRunFlagFor, "for", "5m", "duration of the run, e.g.: 30s, 5m")
+}
@@ -3,6 +3,7 @@ package cmd
 import (
 	"log"
 	"os"
+	"time"
 
 	"github.com/peter-vaczi/sprinkler/core"
 	"github.com/peter-vaczi/sprinkler/utils"
@@ -12,6 +13,13 @@ import (
 var setFlagOn bool
 var setFlagOff bool
 var setFlagPin int = -1
+var setFlagRole string
+var setFlagLead time.Duration = -1
+var setFlagLag time.Duration = -1
+var setFlagMaxOn time.Duration = -1
+var setFlagFlow float64 = -1
+var setFlagOpenSettle time.Duration = -1
+var setFlagCloseSettle time.Duration = -1
 
 // deviceSetCmd represents the add command
 var deviceSetCmd = &cobra.Command{
@@ -41,6 +49,27 @@ var deviceSetCmd = &cobra.Command{
 		if setFlagOff {
 			dev.On = false
 		}
+		if 0 < len(setFlagRole) {
+			dev.Role = setFlagRole
+		}
+		if setFlagLead != -1 {
+			dev.Lead = setFlagLead
+		}
+		if setFlagLag != -1 {
+			dev.Lag = setFlagLag
+		}
+		if setFlagMaxOn != -1 {
+			dev.MaxOn = setFlagMaxOn
+		}
+		if setFlagFlow != -1 {
+			dev.Flow = setFlagFlow
+		}
+		if setFlagOpenSettle != -1 {
+			dev.OpenSettle = setFlagOpenSettle
+		}
+		if setFlagCloseSettle != -1 {
+			dev.CloseSettle = setFlagCloseSettle
+		}
 
 		err = utils.PutRequest(daemonSocket+"/v1/devices/"+dev.Name, &dev)
 		if err != nil {
@@ -54,4 +83,11 @@ func init() {
 	deviceSetCmd.PersistentFlags().IntVar(&setFlagPin, "pin", -1, "GPIO pin associated with this device")
 	deviceSetCmd.PersistentFlags().BoolVar(&setFlagOn, "on", false, "set the device on")
 	deviceSetCmd.PersistentFlags().BoolVar(&setFlagOff, "off", false, "set the device off")
+	deviceSetCmd.PersistentFlags().StringVar(&setFlagRole, "role", "", "role of the device: zone, master or pump")
+	deviceSetCmd.PersistentFlags().DurationVar(&setFlagLead, "lead", -1, "master only: switch on this long before the first zone opens")
+	deviceSetCmd.PersistentFlags().DurationVar(&setFlagLag, "lag", -1, "master only: switch off this long after the last zone closes")
+	deviceSetCmd.PersistentFlags().DurationVar(&setFlagMaxOn, "max-on", -1, "switch the device off if it is on for longer than this, 0 means no limit")
+	deviceSetCmd.PersistentFlags().Float64Var(&setFlagFlow, "flow", -1, "expected flow in liters per minute, 0 means learn it from the flow meter")
+	deviceSetCmd.PersistentFlags().DurationVar(&setFlagOpenSettle, "open-settle", -1, "time the valve needs to open fully, not counted as watering time")
+	deviceSetCmd.PersistentFlags().DurationVar(&setFlagCloseSettle, "close-settle", -1, "time the valve needs to close fully before the next one opens")
 }
@@ -40,14 +40,22 @@ func printDevices(devs core.Devices) {
 	w := new(tabwriter.Writer)
 
 	w.Init(os.Stdout, 5, 0, 1, ' ', 0)
-	fmt.Fprintln(w, "NAME\tPIN\tSTATUS\t")
+	fmt.Fprintln(w, "NAME\tPIN\tROLE\tFLOW\tSTATUS\t")
 
 	for _, k := range keys {
 		onoff := "off"
 		if devs[k].On {
 			onoff = "on"
 		}
-		fmt.Fprintf(w, "%s\t%d\t%s\t\n", devs[k].Name, devs[k].Pin, onoff)
+		role := devs[k].Role
+		if len(role) == 0 {
+			role = core.RoleZone
+		}
+		flow := "-"
+		if devs[k].Flow > 0 {
+			flow = fmt.Sprintf("%.1f l/min", devs[k].Flow)
+		}
+		fmt.Fprintf(w, "%s\t%d\t%s\t%s\t%s\t\n", devs[k].Name, devs[k].Pin, role, flow, onoff)
 	}
 
 	w.Flush()
@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/peter-vaczi/sprinkler/core"
+	"github.com/peter-vaczi/sprinkler/utils"
+)
+
+var eventsFlagSince string
+var eventsFlagClear bool
+
+// eventsCmd represents the events command
+var eventsCmd = &cobra.Command{
+	Use:   "events",
+	Short: "Show the faults detected by the controller",
+	Long: `Show the faults detected by the controller, e.g. leaks and broken heads,
+e.g.: events --since 24h`,
+	Run: func(cmd *cobra.Command, args []string) {
+
+		if len(args) != 0 {
+			cmd.Usage()
+			os.Exit(-1)
+		}
+
+		if eventsFlagClear {
+			err := utils.DeleteRequest(daemonSocket + "/v1/events")
+			if err != nil {
+				log.Fatal(err)
+			}
+			return
+		}
+
+		query := url.Values{}
+		if eventsFlagSince != "" {
+			// a duration means that long ago
+			since, err := parseTime("-" + eventsFlagSince)
+			if err != nil {
+				since, err = parseTime(eventsFlagSince)
+			}
+			if err != nil {
+				log.Fatal(err)
+			}
+			query.Set("since", since.Format(time.RFC3339))
+		}
+
+		var events []*core.Event
+		err := utils.GetRequest(daemonSocket+"/v1/events?"+query.Encode(), &events)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		printEvents(events)
+	},
+}
+
+func printEvents(events []*core.Event) {
+	w := new(tabwriter.Writer)
+
+	w.Init(os.Stdout, 5, 0, 1, ' ', 0)
+	fmt.Fprintln(w, "TIME\tTYPE\tMESSAGE\t")
+
+	for _, event := range events {
+		fmt.Fprintf(w, "%s\t%s\t%s\t\n", event.Time.Local().Format("2006-01-02 15:04:05"), event.Type, event.Message)
+	}
+
+	w.Flush()
+}
+
+func init() {
+	eventsCmd.Flags().StringVar(&eventsFlagSince, "since", "", "show the events since this time or for this long, e.g. 24h")
+	eventsCmd.Flags().BoolVar(&eventsFlagClear, "clear", false, "clear the events")
+	RootCmd.AddCommand(eventsCmd)
+}
@@ -10,12 +10,21 @@ import (
 )
 
 var programAddDeviceDuration string
+var programAddDeviceParallel []string
+var programAddDeviceCycle string
+var programAddDeviceSoak string
+var programAddDeviceVolume float64
+var programAddDeviceAt int
 
 // programAddDeviceCmd represents the adddevice command
 var programAddDeviceCmd = &cobra.Command{
 	Use:   "adddevice <program> <device>",
 	Short: "Add a new device to a watering program",
-	Long:  `Add a new device to a watering program`,
+	Long: `Add a new device to a watering program, it is opened for the given duration
+or until the given volume is measured by the flow sensor, in which case the
+duration is the longest it may run, e.g.: adddevice pr1 dev1 --volume 200 -d 30m
+The device is appended to the program unless --at inserts it before the
+element of the given number.`,
 	Run: func(cmd *cobra.Command, args []string) {
 
 		if len(args) != 2 {
@@ -23,12 +32,20 @@ var programAddDeviceCmd = &cobra.Command{
 			os.Exit(-1)
 		}
 
-		data := make(map[string]string)
+		data := make(map[string]interface{})
 		data["device"] = args[1]
-		data["duration"] = programAddDeviceDuration
-		err := utils.PostRequest(
-			fmt.Sprintf("%s/v1/programs/%s/devices", daemonSocket, args[0]),
-			&data)
+		if programAddDeviceVolume == 0 || cmd.Flags().Changed("duration") {
+			data["duration"] = programAddDeviceDuration
+		}
+		data["volume"] = programAddDeviceVolume
+		data["parallel"] = programAddDeviceParallel
+		data["cycle"] = programAddDeviceCycle
+		data["soak"] = programAddDeviceSoak
+		url := fmt.Sprintf("%s/v1/programs/%s/devices", daemonSocket, args[0])
+		if programAddDeviceAt != -1 {
+			url = fmt.Sprintf("%s/%d", url, programAddDeviceAt)
+		}
+		err := utils.PostRequest(url, &data)
 		if err != nil {
 			log.Fatal(err)
 		}
@@ -37,5 +54,10 @@ var programAddDeviceCmd = &cobra.Command{
 
 func init() {
 	programAddDeviceCmd.Flags().StringVarP(&programAddDeviceDuration, "duration", "d", "15m", "duration of , e.g.: 1s, 2m, 3h, 2h45m")
+	programAddDeviceCmd.Flags().StringVar(&programAddDeviceCycle, "cycle", "", "maximal time to run in one cycle, e.g.: 6m")
+	programAddDeviceCmd.Flags().StringVar(&programAddDeviceSoak, "soak", "", "minimal time to wait between two cycles, e.g.: 30m")
+	programAddDeviceCmd.Flags().Float64Var(&programAddDeviceVolume, "volume", 0, "liters of water to deliver instead of a duration")
+	programAddDeviceCmd.Flags().IntVar(&programAddDeviceAt, "at", -1, "number of the element to insert the device before")
+	programAddDeviceCmd.Flags().StringSliceVarP(&programAddDeviceParallel, "parallel", "p", nil, "devices to open together with the device, e.g.: dev2,dev3")
 	programCmd.AddCommand(programAddDeviceCmd)
 }
@@ -11,9 +11,10 @@ import (
 
 // programDelDeviceCmd represents the deldevice command
 var programDelDeviceCmd = &cobra.Command{
-	Use:   "deldevice <program> <device>",
+	Use:   "deldevice <program> <nr>",
 	Short: "Delete a device from a watering program",
-	Long:  `Delete a device from a watering program`,
+	Long: `Delete a device from a watering program, the element is given by its number
+shown by program show`,
 	Run: func(cmd *cobra.Command, args []string) {
 
 		if len(args) != 2 {
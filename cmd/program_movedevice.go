@@ -0,0 +1,36 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/peter-vaczi/sprinkler/utils"
+	"github.com/spf13/cobra"
+)
+
+// programMoveDeviceCmd represents the movedevice command
+var programMoveDeviceCmd = &cobra.Command{
+	Use:   "movedevice <program> <nr> <to>",
+	Short: "Move an element of a watering program",
+	Long: `Move an element of a watering program to another position, the elements are
+given by their numbers shown by program show, e.g.: movedevice pr1 3 0`,
+	Run: func(cmd *cobra.Command, args []string) {
+
+		if len(args) != 3 {
+			cmd.Usage()
+			os.Exit(-1)
+		}
+
+		err := utils.PostRequest(
+			fmt.Sprintf("%s/v1/programs/%s/devices/%s/move?to=%s", daemonSocket, args[0], args[1], args[2]),
+			nil)
+		if err != nil {
+			log.Fatal(err)
+		}
+	},
+}
+
+func init() {
+	programCmd.AddCommand(programMoveDeviceCmd)
+}
@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/peter-vaczi/sprinkler/utils"
+	"github.com/spf13/cobra"
+)
+
+// programNextCmd represents the next command
+var programNextCmd = &cobra.Command{
+	Use:   "next <name>",
+	Short: "Skip to the next step of a running watering program",
+	Long:  `Close the open valves of a running watering program and continue with its next step`,
+	Run: func(cmd *cobra.Command, args []string) {
+
+		if len(args) != 1 {
+			cmd.Usage()
+			os.Exit(-1)
+		}
+
+		err := utils.PostRequest(
+			fmt.Sprintf("%s/v1/programs/%s/next", daemonSocket, args[0]), nil)
+		if err != nil {
+			log.Fatal(err)
+		}
+	},
+}
+
+func init() {
+	programCmd.AddCommand(programNextCmd)
+}
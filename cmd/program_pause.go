@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/peter-vaczi/sprinkler/utils"
+	"github.com/spf13/cobra"
+)
+
+// programPauseCmd represents the pause command
+var programPauseCmd = &cobra.Command{
+	Use:   "pause <name>",
+	Short: "Pause a running watering program",
+	Long:  `Pause a running watering program keeping the remaining time of its current element`,
+	Run: func(cmd *cobra.Command, args []string) {
+
+		if len(args) != 1 {
+			cmd.Usage()
+			os.Exit(-1)
+		}
+
+		err := utils.PostRequest(
+			fmt.Sprintf("%s/v1/programs/%s/pause", daemonSocket, args[0]), nil)
+		if err != nil {
+			log.Fatal(err)
+		}
+	},
+}
+
+func init() {
+	programCmd.AddCommand(programPauseCmd)
+}
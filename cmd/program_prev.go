@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/peter-vaczi/sprinkler/utils"
+	"github.com/spf13/cobra"
+)
+
+// programPrevCmd represents the prev command
+var programPrevCmd = &cobra.Command{
+	Use:   "prev <name>",
+	Short: "Go back to the previous step of a running watering program",
+	Long:  `Close the open valves of a running watering program and continue with its previous step`,
+	Run: func(cmd *cobra.Command, args []string) {
+
+		if len(args) != 1 {
+			cmd.Usage()
+			os.Exit(-1)
+		}
+
+		err := utils.PostRequest(
+			fmt.Sprintf("%s/v1/programs/%s/prev", daemonSocket, args[0]), nil)
+		if err != nil {
+			log.Fatal(err)
+		}
+	},
+}
+
+func init() {
+	programCmd.AddCommand(programPrevCmd)
+}
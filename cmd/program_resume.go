@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/peter-vaczi/sprinkler/utils"
+	"github.com/spf13/cobra"
+)
+
+// programResumeCmd represents the resume command
+var programResumeCmd = &cobra.Command{
+	Use:   "resume <name>",
+	Short: "Resume a paused watering program",
+	Long:  `Resume a paused watering program from the element and the remaining time it was paused at`,
+	Run: func(cmd *cobra.Command, args []string) {
+
+		if len(args) != 1 {
+			cmd.Usage()
+			os.Exit(-1)
+		}
+
+		err := utils.PostRequest(
+			fmt.Sprintf("%s/v1/programs/%s/resume", daemonSocket, args[0]), nil)
+		if err != nil {
+			log.Fatal(err)
+		}
+	},
+}
+
+func init() {
+	programCmd.AddCommand(programResumeCmd)
+}
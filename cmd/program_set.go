@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"log"
+	"os"
+	"time"
+
+	"github.com/peter-vaczi/sprinkler/core"
+	"github.com/peter-vaczi/sprinkler/utils"
+	"github.com/spf13/cobra"
+)
+
+var programSetDelay time.Duration = -1
+var programSetOverlap time.Duration = -1
+
+// programSetCmd represents the set command
+var programSetCmd = &cobra.Command{
+	Use:   "set <name> [flags]",
+	Short: "Set parameters of a watering program",
+	Long: `Set parameters of a watering program: the delay between two elements, or the
+overlap with which the next valve opens before the previous one closes,
+e.g.: set pr1 --delay 5s`,
+	Run: func(cmd *cobra.Command, args []string) {
+
+		if len(args) != 1 {
+			cmd.Usage()
+			os.Exit(-1)
+		}
+
+		var prg core.Program
+
+		err := utils.GetRequest(daemonSocket+"/v1/programs/"+args[0], &prg)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		if programSetDelay != -1 {
+			prg.Delay = &programSetDelay
+		}
+		if programSetOverlap != -1 {
+			prg.Overlap = programSetOverlap
+		}
+
+		err = utils.PutRequest(daemonSocket+"/v1/programs/"+args[0], &prg)
+		if err != nil {
+			log.Fatal(err)
+		}
+	},
+}
+
+func init() {
+	programCmd.AddCommand(programSetCmd)
+	programSetCmd.Flags().DurationVar(&programSetDelay, "delay", -1, "pause between two elements, 0 means no pause (default of a program 1s)")
+	programSetCmd.Flags().DurationVar(&programSetOverlap, "overlap", -1, "open the next valve this long before the previous one closes, 0 means no overlap")
+}
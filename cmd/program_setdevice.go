@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+
+	"github.com/peter-vaczi/sprinkler/core"
+	"github.com/peter-vaczi/sprinkler/utils"
+	"github.com/spf13/cobra"
+)
+
+var programSetDeviceDevice string
+var programSetDeviceDuration string
+var programSetDeviceParallel []string
+var programSetDeviceCycle string
+var programSetDeviceSoak string
+var programSetDeviceVolume float64
+
+// programSetDeviceCmd represents the setdevice command
+var programSetDeviceCmd = &cobra.Command{
+	Use:   "setdevice <program> <nr> [flags]",
+	Short: "Change an element of a watering program",
+	Long: `Change an element of a watering program, the element is given by its number
+shown by program show, the parameters not given are kept,
+e.g.: setdevice pr1 2 --device dev4 -d 10m`,
+	Run: func(cmd *cobra.Command, args []string) {
+
+		if len(args) != 2 {
+			cmd.Usage()
+			os.Exit(-1)
+		}
+		idx, err := strconv.Atoi(args[1])
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		var prg core.Program
+
+		err = utils.GetRequest(daemonSocket+"/v1/programs/"+args[0], &prg)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if idx < 0 || idx >= len(prg.Elements) {
+			log.Fatal(core.OutOfRange)
+		}
+		elem := prg.Elements[idx]
+
+		data := make(map[string]interface{})
+		data["device"] = elem.DeviceName
+		data["duration"] = elem.Duration.String()
+		data["parallel"] = elem.ParallelNames
+		data["cycle"] = elem.Cycle.String()
+		data["soak"] = elem.Soak.String()
+		data["volume"] = elem.Volume
+		if cmd.Flags().Changed("device") {
+			data["device"] = programSetDeviceDevice
+		}
+		if cmd.Flags().Changed("duration") {
+			data["duration"] = programSetDeviceDuration
+		}
+		if cmd.Flags().Changed("parallel") {
+			data["parallel"] = programSetDeviceParallel
+		}
+		if cmd.Flags().Changed("cycle") {
+			data["cycle"] = programSetDeviceCycle
+		}
+		if cmd.Flags().Changed("soak") {
+			data["soak"] = programSetDeviceSoak
+		}
+		if cmd.Flags().Changed("volume") {
+			data["volume"] = programSetDeviceVolume
+		}
+
+		err = utils.PutRequest(
+			fmt.Sprintf("%s/v1/programs/%s/devices/%d", daemonSocket, args[0], idx),
+			&data)
+		if err != nil {
+			log.Fatal(err)
+		}
+	},
+}
+
+func init() {
+	programSetDeviceCmd.Flags().StringVar(&programSetDeviceDevice, "device", "", "device to open instead of the current one")
+	programSetDeviceCmd.Flags().StringVarP(&programSetDeviceDuration, "duration", "d", "", "duration, e.g.: 1s, 2m, 3h, 2h45m")
+	programSetDeviceCmd.Flags().StringVar(&programSetDeviceCycle, "cycle", "", "maximal time to run in one cycle, e.g.: 6m, 0s means no cycles")
+	programSetDeviceCmd.Flags().StringVar(&programSetDeviceSoak, "soak", "", "minimal time to wait between two cycles, e.g.: 30m")
+	programSetDeviceCmd.Flags().Float64Var(&programSetDeviceVolume, "volume", 0, "liters of water to deliver instead of a duration, 0 means time based")
+	programSetDeviceCmd.Flags().StringSliceVarP(&programSetDeviceParallel, "parallel", "p", nil, "devices to open together with the device, e.g.: dev2,dev3")
+	programCmd.AddCommand(programSetDeviceCmd)
+}
@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/peter-vaczi/sprinkler/utils"
+	"github.com/spf13/cobra"
+)
+
+// programSetDevicesCmd represents the setdevices command
+var programSetDevicesCmd = &cobra.Command{
+	Use:   "setdevices <program> <file>",
+	Short: "Replace every element of a watering program",
+	Long: `Replace every element of a watering program with the ones in the file, - reads
+the standard input, nothing is changed if any of the elements is invalid,
+e.g.: [{"device":"dev1","duration":"10m"},{"device":"dev2","volume":200,"duration":"30m"}]`,
+	Run: func(cmd *cobra.Command, args []string) {
+
+		if len(args) != 2 {
+			cmd.Usage()
+			os.Exit(-1)
+		}
+
+		var in io.Reader = os.Stdin
+		if args[1] != "-" {
+			f, err := os.Open(args[1])
+			if err != nil {
+				log.Fatal(err)
+			}
+			defer f.Close()
+			in = f
+		}
+
+		var data []map[string]interface{}
+		if err := json.NewDecoder(in).Decode(&data); err != nil {
+			log.Fatal(err)
+		}
+
+		err := utils.PutRequest(
+			fmt.Sprintf("%s/v1/programs/%s/devices", daemonSocket, args[0]),
+			&data)
+		if err != nil {
+			log.Fatal(err)
+		}
+	},
+}
+
+func init() {
+	programCmd.AddCommand(programSetDevicesCmd)
+}
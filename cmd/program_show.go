@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strings"
 	"text/tabwriter"
 
 	"github.com/peter-vaczi/sprinkler/core"
@@ -23,20 +24,40 @@ var programShowCmd = &cobra.Command{
 			os.Exit(-1)
 		}
 
-		var prg core.Program
+		var prg struct {
+			core.Program
+			Scale    int          `json:"scale"`
+			Timeline []*core.Step `json:"timeline"`
+		}
 
 		err := utils.GetRequest(daemonSocket+"/v1/programs/"+args[0], &prg)
 		if err != nil {
 			log.Fatal(err)
 		}
 
-		fmt.Printf("Name: %s\n\n", prg.Name)
+		fmt.Printf("Name: %s\n", prg.Name)
+		fmt.Printf("Scale: %d%%\n", prg.Scale)
+		if prg.Delay != nil {
+			fmt.Printf("Delay: %s\n", *prg.Delay)
+		}
+		if prg.Overlap != 0 {
+			fmt.Printf("Overlap: %s\n", prg.Overlap)
+		}
+		fmt.Println()
 		w := new(tabwriter.Writer)
 		w.Init(os.Stdout, 5, 0, 1, ' ', 0)
-		fmt.Fprintln(w, "NR\tDEVICE\tDURATION\t")
+		fmt.Fprintln(w, "NR\tDEVICE\tDURATION\tVOLUME\tCYCLE\tSOAK\t")
 
 		for i, e := range prg.Elements {
-			fmt.Fprintf(w, "%d\t%s\t%s\t\n", i, e.DeviceName, e.Duration)
+			devices := append([]string{e.DeviceName}, e.ParallelNames...)
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\t\n", i, strings.Join(devices, "+"), e.Duration, liters(e.Volume), e.Cycle, e.Soak)
+		}
+
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "START\tNR\tCYCLE\tDEVICE\tDURATION\tVOLUME\t")
+
+		for _, s := range prg.Timeline {
+			fmt.Fprintf(w, "%s\t%d\t%d\t%s\t%s\t%s\t\n", s.Start, s.Element, s.Cycle+1, strings.Join(s.Devices, "+"), s.Duration, liters(s.Volume))
 		}
 
 		fmt.Fprintln(w)
@@ -44,6 +65,14 @@ var programShowCmd = &cobra.Command{
 	},
 }
 
+// liters formats a volume, 0 means the element is time based
+func liters(volume float64) string {
+	if volume == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.1f l", volume)
+}
+
 func init() {
 	programCmd.AddCommand(programShowCmd)
 }
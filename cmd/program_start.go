@@ -4,16 +4,22 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"time"
 
+	"github.com/peter-vaczi/sprinkler/core"
 	"github.com/peter-vaczi/sprinkler/utils"
 	"github.com/spf13/cobra"
 )
 
+var programStartFlagAt string
+var programStartFlagIn time.Duration
+
 // programStartCmd represents the add command
 var programStartCmd = &cobra.Command{
-	Use:   "start <name>",
+	Use:   "start <name> [flags]",
 	Short: "Start a watering program",
-	Long:  `Start a  watering program`,
+	Long: `Start a watering program now or once later,
+e.g.: program start lawn --at "2026-10-19 06:30"`,
 	Run: func(cmd *cobra.Command, args []string) {
 
 		if len(args) != 1 {
@@ -21,6 +27,37 @@ var programStartCmd = &cobra.Command{
 			os.Exit(-1)
 		}
 
+		if programStartFlagAt != "" || programStartFlagIn != 0 {
+			at := time.Now().Add(programStartFlagIn)
+			if programStartFlagAt != "" {
+				var err error
+				at, err = parseTime(programStartFlagAt)
+				if err != nil {
+					log.Fatal(err)
+				}
+			}
+			// the one-shot schedules run at whole minutes, the start is not
+			// moved before the requested time
+			if rounded := at.Truncate(time.Minute); !rounded.Equal(at) {
+				at = rounded.Add(time.Minute)
+			}
+
+			// a one-shot schedule deleting itself after the run, the time is
+			// sent with its offset, the daemon may run in another time zone
+			sch := core.Schedule{
+				Name:        fmt.Sprintf("%s-once-%s", args[0], at.Format("200601021504")),
+				ProgramName: args[0],
+				Spec:        fmt.Sprintf("%s %s %s", core.Once, at.Format(time.RFC3339), core.OnceDelete),
+				Enabled:     true,
+			}
+			err := utils.PostRequest(daemonSocket+"/v1/schedules", &sch)
+			if err != nil {
+				log.Fatal(err)
+			}
+			fmt.Printf("program %s will start at %s by schedule %s\n", args[0], at.Format("2006-01-02 15:04"), sch.Name)
+			return
+		}
+
 		err := utils.PostRequest(
 			fmt.Sprintf("%s/v1/programs/%s/start", daemonSocket, args[0]), nil)
 		if err != nil {
@@ -31,4 +68,6 @@ var programStartCmd = &cobra.Command{
 
 func init() {
 	programCmd.AddCommand(programStartCmd)
+	programStartCmd.PersistentFlags().StringVar(&programStartFlagAt, "at", "", "start the program once at this time, e.g. 2026-10-19 06:30")
+	programStartCmd.PersistentFlags().DurationVar(&programStartFlagIn, "in", 0, "start the program once after this long, e.g. 2h")
 }
@@ -5,7 +5,9 @@ import (
 	"log"
 	"os"
 	"sort"
+	"strings"
 	"text/tabwriter"
+	"time"
 
 	"github.com/spf13/cobra"
 
@@ -13,13 +15,19 @@ import (
 	"github.com/peter-vaczi/sprinkler/utils"
 )
 
+// program is a program with its live status as listed by the daemon
+type program struct {
+	core.Program
+	Status *core.ProgramStatus `json:"status"`
+}
+
 var programStatusCmd = &cobra.Command{
 	Use:   "status",
 	Short: "Show status",
 	Long:  `Show status`,
 	Run: func(cmd *cobra.Command, args []string) {
 
-		var progs core.Programs
+		var progs map[string]*program
 
 		err := utils.GetRequest(daemonSocket+"/v1/programs", &progs)
 		if err != nil {
@@ -30,7 +38,7 @@ var programStatusCmd = &cobra.Command{
 	},
 }
 
-func printPrograms(progs core.Programs) {
+func printPrograms(progs map[string]*program) {
 	keys := make([]string, 0, len(progs))
 	for k := range progs {
 		keys = append(keys, k)
@@ -40,10 +48,37 @@ func printPrograms(progs core.Programs) {
 	w := new(tabwriter.Writer)
 
 	w.Init(os.Stdout, 5, 0, 1, ' ', 0)
-	fmt.Fprintln(w, "NAME\t")
+	fmt.Fprintln(w, "NAME\tSTATE\tSTEP\tELEMENT\tDEVICES\tELAPSED\tREMAINING\tTOTAL\tSTARTED\tSOURCE\t")
 
 	for _, k := range keys {
-		fmt.Fprintf(w, "%s\t\n", progs[k].Name)
+		st := progs[k].Status
+		if st == nil {
+			st = &core.ProgramStatus{State: core.StateIdle}
+		}
+		step, elem, devices, started, source := "-", "-", "-", "-", "-"
+		elapsed, remaining, total := "-", "-", "-"
+		if st.Step != nil {
+			step = fmt.Sprint(*st.Step)
+			remaining = st.Remaining.Round(time.Second).String()
+		}
+		if st.Element != nil {
+			elem = fmt.Sprint(*st.Element)
+		}
+		if len(st.Devices) != 0 {
+			devices = strings.Join(st.Devices, ",")
+			elapsed = st.Elapsed.Round(time.Second).String()
+		}
+		if st.State == core.StateRunning {
+			total = st.TotalRemaining.Round(time.Second).String()
+		}
+		if st.Started != nil {
+			started = st.Started.Format("2006-01-02 15:04:05")
+		}
+		if st.Source != "" {
+			source = st.Source
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t\n", progs[k].Name, st.State,
+			step, elem, devices, elapsed, remaining, total, started, source)
 	}
 
 	w.Flush()
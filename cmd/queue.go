@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/peter-vaczi/sprinkler/core"
+	"github.com/peter-vaczi/sprinkler/utils"
+)
+
+var queueFlagClear bool
+var queueFlagDel int = -1
+
+// queueCmd represents the queue command
+var queueCmd = &cobra.Command{
+	Use:   "queue",
+	Short: "Show the programs waiting for the running programs to finish",
+	Long: `Show the programs waiting for the running programs to finish,
+e.g.: queue --del 0`,
+	Run: func(cmd *cobra.Command, args []string) {
+
+		if len(args) != 0 {
+			cmd.Usage()
+			os.Exit(-1)
+		}
+
+		if queueFlagClear {
+			err := utils.DeleteRequest(daemonSocket + "/v1/queue")
+			if err != nil {
+				log.Fatal(err)
+			}
+			return
+		}
+		if queueFlagDel != -1 {
+			err := utils.DeleteRequest(fmt.Sprintf("%s/v1/queue/%d", daemonSocket, queueFlagDel))
+			if err != nil {
+				log.Fatal(err)
+			}
+			return
+		}
+
+		var entries []*core.QueueEntry
+		err := utils.GetRequest(daemonSocket+"/v1/queue", &entries)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		printQueue(entries)
+	},
+}
+
+func printQueue(entries []*core.QueueEntry) {
+	w := new(tabwriter.Writer)
+
+	w.Init(os.Stdout, 5, 0, 1, ' ', 0)
+	fmt.Fprintln(w, "IDX\tPROGRAM\tSOURCE\tQUEUED\t")
+
+	for idx, entry := range entries {
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t\n", idx, entry.Program, entry.Source, entry.Time.Local().Format("2006-01-02 15:04:05"))
+	}
+
+	w.Flush()
+}
+
+func init() {
+	queueCmd.Flags().BoolVar(&queueFlagClear, "clear", false, "remove every queued program")
+	queueCmd.Flags().IntVar(&queueFlagDel, "del", -1, "remove the queued program at this index")
+	RootCmd.AddCommand(queueCmd)
+}
@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/peter-vaczi/sprinkler/utils"
+)
+
+var rainDelayFlagClear bool
+
+// rainDelay is the body of the rain delay requests
+type rainDelay struct {
+	Until *time.Time `json:"until,omitempty"`
+}
+
+// rainDelayCmd represents the raindelay command
+var rainDelayCmd = &cobra.Command{
+	Use:   "raindelay [<duration>|<time>]",
+	Short: "Suspend every schedule for a while",
+	Long: `Suspend every schedule until the given time, e.g.: raindelay 48h
+Without arguments the current rain delay is shown.`,
+	Run: func(cmd *cobra.Command, args []string) {
+
+		if len(args) > 1 || (rainDelayFlagClear && len(args) != 0) {
+			cmd.Usage()
+			os.Exit(-1)
+		}
+
+		if rainDelayFlagClear {
+			err := utils.DeleteRequest(daemonSocket + "/v1/raindelay")
+			if err != nil {
+				log.Fatal(err)
+			}
+			return
+		}
+
+		if len(args) == 1 {
+			until, err := parseTime(args[0])
+			if err != nil {
+				log.Fatal(err)
+			}
+			err = utils.PutRequest(daemonSocket+"/v1/raindelay", &rainDelay{Until: &until})
+			if err != nil {
+				log.Fatal(err)
+			}
+			return
+		}
+
+		printRainDelay()
+	},
+}
+
+func printRainDelay() {
+	var delay rainDelay
+
+	err := utils.GetRequest(daemonSocket+"/v1/raindelay", &delay)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if delay.Until != nil {
+		fmt.Printf("Rain delay until %s\n", delay.Until.Local().Format(time.RFC1123))
+	} else {
+		fmt.Println("No rain delay")
+	}
+}
+
+func init() {
+	rainDelayCmd.Flags().BoolVar(&rainDelayFlagClear, "clear", false, "clear the rain delay")
+	RootCmd.AddCommand(rainDelayCmd)
+}
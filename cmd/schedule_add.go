@@ -12,6 +12,8 @@ import (
 var scheduleAddFlagEnable bool
 var scheduleAddFlagProgram string
 var scheduleAddFlagSpec string
+var scheduleAddFlagActiveFrom string
+var scheduleAddFlagActiveTo string
 
 // scheduleAddCmd represents the add command
 var scheduleAddCmd = &cobra.Command{
@@ -25,7 +27,8 @@ var scheduleAddCmd = &cobra.Command{
 			os.Exit(-1)
 		}
 
-		sch := core.Schedule{Name: args[0], ProgramName: scheduleAddFlagProgram, Spec: scheduleAddFlagSpec, Enabled: scheduleAddFlagEnable}
+		sch := core.Schedule{Name: args[0], ProgramName: scheduleAddFlagProgram, Spec: scheduleAddFlagSpec, Enabled: scheduleAddFlagEnable,
+			ActiveFrom: scheduleAddFlagActiveFrom, ActiveTo: scheduleAddFlagActiveTo}
 		err := utils.PostRequest(daemonSocket+"/v1/schedules", &sch)
 		if err != nil {
 			log.Fatal(err)
@@ -36,6 +39,8 @@ var scheduleAddCmd = &cobra.Command{
 func init() {
 	scheduleCmd.AddCommand(scheduleAddCmd)
 	scheduleAddCmd.PersistentFlags().StringVar(&scheduleAddFlagProgram, "program", "", "the program to start")
-	scheduleAddCmd.PersistentFlags().StringVar(&scheduleAddFlagSpec, "spec", "", "the scheduling specification")
+	scheduleAddCmd.PersistentFlags().StringVar(&scheduleAddFlagSpec, "spec", "", "the scheduling specification: a cron spec, @sunrise|@sunset [offset] [finish], @interval <days> <first day> <HH:MM>, @odd|@even <HH:MM> [skip-31st] [skip-feb29], @weekdays <days> <HH:MM> or @once <YYYY-MM-DD> <HH:MM>|<RFC3339 time> [delete]")
+	scheduleAddCmd.PersistentFlags().StringVar(&scheduleAddFlagActiveFrom, "active-from", "", "first day of the year the schedule is active on, e.g. 04-15")
+	scheduleAddCmd.PersistentFlags().StringVar(&scheduleAddFlagActiveTo, "active-to", "", "last day of the year the schedule is active on, e.g. 10-15")
 	scheduleAddCmd.PersistentFlags().BoolVar(&scheduleAddFlagEnable, "enable", false, "enable the schedule")
 }
@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/peter-vaczi/sprinkler/utils"
+	"github.com/spf13/cobra"
+)
+
+var schedulePauseFlagUntil string
+var schedulePauseFlagClear bool
+
+// schedulePauseCmd represents the pause command
+var schedulePauseCmd = &cobra.Command{
+	Use:   "pause <name> [flags]",
+	Short: "Pause a schedule until the given time",
+	Long: `Pause a schedule until the given time or for the given duration,
+e.g.: schedule pause lawn --until 72h`,
+	Run: func(cmd *cobra.Command, args []string) {
+
+		if len(args) != 1 {
+			cmd.Usage()
+			os.Exit(-1)
+		}
+
+		path := fmt.Sprintf("%s/v1/schedules/%s/pause", daemonSocket, args[0])
+		if schedulePauseFlagClear {
+			err := utils.DeleteRequest(path)
+			if err != nil {
+				log.Fatal(err)
+			}
+			return
+		}
+
+		until, err := parseTime(schedulePauseFlagUntil)
+		if err != nil {
+			log.Fatal(err)
+		}
+		query := url.Values{}
+		query.Set("until", until.Format(time.RFC3339))
+		err = utils.PostRequest(path+"?"+query.Encode(), nil)
+		if err != nil {
+			log.Fatal(err)
+		}
+	},
+}
+
+func init() {
+	scheduleCmd.AddCommand(schedulePauseCmd)
+	schedulePauseCmd.PersistentFlags().StringVar(&schedulePauseFlagUntil, "until", "", "pause the schedule until this time or for this long, e.g. 2026-10-25 or 72h")
+	schedulePauseCmd.PersistentFlags().BoolVar(&schedulePauseFlagClear, "clear", false, "cancel the pause")
+}
@@ -13,6 +13,8 @@ var scheduleSetFlagEnable bool
 var scheduleSetFlagDisable bool
 var scheduleSetFlagProgram string
 var scheduleSetFlagSpec string
+var scheduleSetFlagActiveFrom string
+var scheduleSetFlagActiveTo string
 
 // scheduleSetCmd represents the add command
 var scheduleSetCmd = &cobra.Command{
@@ -39,6 +41,12 @@ var scheduleSetCmd = &cobra.Command{
 		if 0 < len(scheduleSetFlagSpec) {
 			sch.Spec = scheduleSetFlagSpec
 		}
+		if cmd.Flags().Changed("active-from") {
+			sch.ActiveFrom = scheduleSetFlagActiveFrom
+		}
+		if cmd.Flags().Changed("active-to") {
+			sch.ActiveTo = scheduleSetFlagActiveTo
+		}
 		if scheduleSetFlagEnable {
 			sch.Enabled = true
 		}
@@ -56,7 +64,9 @@ var scheduleSetCmd = &cobra.Command{
 func init() {
 	scheduleCmd.AddCommand(scheduleSetCmd)
 	scheduleSetCmd.PersistentFlags().StringVar(&scheduleSetFlagProgram, "program", "", "program to start when this schedule became active")
-	scheduleSetCmd.PersistentFlags().StringVar(&scheduleSetFlagSpec, "spec", "", "the scheduling specification")
+	scheduleSetCmd.PersistentFlags().StringVar(&scheduleSetFlagActiveFrom, "active-from", "", "first day of the year the schedule is active on, e.g. 04-15, empty means Jan 1")
+	scheduleSetCmd.PersistentFlags().StringVar(&scheduleSetFlagActiveTo, "active-to", "", "last day of the year the schedule is active on, e.g. 10-15, empty means Dec 31")
+	scheduleSetCmd.PersistentFlags().StringVar(&scheduleSetFlagSpec, "spec", "", "the scheduling specification: a cron spec, @sunrise|@sunset [offset] [finish], @interval <days> <first day> <HH:MM>, @odd|@even <HH:MM> [skip-31st] [skip-feb29], @weekdays <days> <HH:MM> or @once <YYYY-MM-DD> <HH:MM>|<RFC3339 time> [delete]")
 	scheduleSetCmd.PersistentFlags().BoolVar(&scheduleSetFlagEnable, "enable", false, "enable the schedule")
 	scheduleSetCmd.PersistentFlags().BoolVar(&scheduleSetFlagDisable, "disable", false, "disable the schedule")
 }
@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/peter-vaczi/sprinkler/utils"
+	"github.com/spf13/cobra"
+)
+
+var scheduleSkipFlagCount int
+
+// scheduleSkipCmd represents the skip command
+var scheduleSkipCmd = &cobra.Command{
+	Use:   "skip <name> [flags]",
+	Short: "Skip the next runs of a schedule",
+	Long: `Skip the next runs of a schedule without disabling it,
+e.g.: schedule skip lawn --count 2`,
+	Run: func(cmd *cobra.Command, args []string) {
+
+		if len(args) != 1 {
+			cmd.Usage()
+			os.Exit(-1)
+		}
+
+		err := utils.PostRequest(
+			fmt.Sprintf("%s/v1/schedules/%s/skip?count=%d", daemonSocket, args[0], scheduleSkipFlagCount), nil)
+		if err != nil {
+			log.Fatal(err)
+		}
+	},
+}
+
+func init() {
+	scheduleCmd.AddCommand(scheduleSkipCmd)
+	scheduleSkipCmd.PersistentFlags().IntVar(&scheduleSkipFlagCount, "count", 1, "number of runs to skip, 0 cancels the pending skips")
+}
@@ -6,6 +6,7 @@ import (
 	"os"
 	"sort"
 	"text/tabwriter"
+	"time"
 
 	"github.com/spf13/cobra"
 
@@ -19,18 +20,26 @@ var scheduleStatusCmd = &cobra.Command{
 	Long:  `Show status`,
 	Run: func(cmd *cobra.Command, args []string) {
 
-		var schs core.Schedules
+		var schs map[string]*schedule
 
 		err := utils.GetRequest(daemonSocket+"/v1/schedules", &schs)
 		if err != nil {
 			log.Fatal(err)
 		}
 
+		printRainDelay()
+		fmt.Println()
 		printSchedules(schs)
 	},
 }
 
-func printSchedules(schs core.Schedules) {
+// schedule is a schedule with the time of its next run
+type schedule struct {
+	core.Schedule
+	Next *time.Time `json:"next"`
+}
+
+func printSchedules(schs map[string]*schedule) {
 	keys := make([]string, 0, len(schs))
 	for k := range schs {
 		keys = append(keys, k)
@@ -40,14 +49,36 @@ func printSchedules(schs core.Schedules) {
 	w := new(tabwriter.Writer)
 
 	w.Init(os.Stdout, 5, 0, 1, ' ', 0)
-	fmt.Fprintln(w, "NAME\tENABLED\tPROGRAM\tSPEC\t")
+	fmt.Fprintln(w, "NAME\tENABLED\tPROGRAM\tSPEC\tACTIVE\tNEXT\tHOLD\tLAST RUN\tLAST SKIPPED\t")
 
 	for _, k := range keys {
 		enab := "disabled"
 		if schs[k].Enabled {
 			enab = "enabled"
 		}
-		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t\n", schs[k].Name, enab, schs[k].ProgramName, schs[k].Spec)
+		next := "-"
+		if schs[k].Next != nil {
+			next = schs[k].Next.Local().Format("2006-01-02 15:04")
+		}
+		active := "all year"
+		if schs[k].ActiveFrom != "" || schs[k].ActiveTo != "" {
+			active = fmt.Sprintf("%s..%s", schs[k].ActiveFrom, schs[k].ActiveTo)
+		}
+		hold := "-"
+		if schs[k].PausedUntil != nil && schs[k].PausedUntil.After(time.Now()) {
+			hold = "paused until " + schs[k].PausedUntil.Local().Format("2006-01-02 15:04")
+		} else if schs[k].SkipNext > 0 {
+			hold = fmt.Sprintf("skip %d", schs[k].SkipNext)
+		}
+		last := "-"
+		if schs[k].LastRun != nil {
+			last = schs[k].LastRun.Local().Format("2006-01-02 15:04")
+		}
+		skipped := "-"
+		if schs[k].Skipped != nil {
+			skipped = fmt.Sprintf("%s: %s", schs[k].Skipped.Time.Local().Format("2006-01-02 15:04"), schs[k].Skipped.Reason)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t\n", schs[k].Name, enab, schs[k].ProgramName, schs[k].Spec, active, next, hold, last, skipped)
 	}
 
 	w.Flush()
@@ -0,0 +1,14 @@
+package cmd
+
+import "github.com/spf13/cobra"
+
+// sensorCmd represents the sensor command
+var sensorCmd = &cobra.Command{
+	Use:   "sensor",
+	Short: "Handle sensors wired to gpio inputs",
+	Long:  `Handle sensors wired to gpio inputs`,
+}
+
+func init() {
+	RootCmd.AddCommand(sensorCmd)
+}
@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"log"
+	"os"
+	"time"
+
+	"github.com/peter-vaczi/sprinkler/core"
+	"github.com/peter-vaczi/sprinkler/utils"
+	"github.com/spf13/cobra"
+)
+
+var sensorAddFlagType string
+var sensorAddFlagPin int
+var sensorAddFlagPull string
+var sensorAddFlagActiveLow bool
+var sensorAddFlagDebounce time.Duration
+var sensorAddFlagAbort bool
+var sensorAddFlagKFactor float64
+
+// sensorAddCmd represents the sensor add command
+var sensorAddCmd = &cobra.Command{
+	Use:   "add <name> [flags]",
+	Short: "Add a new sensor",
+	Long:  `Add a new sensor`,
+	Run: func(cmd *cobra.Command, args []string) {
+
+		if len(args) != 1 {
+			cmd.Usage()
+			os.Exit(-1)
+		}
+
+		sensor := core.Sensor{Name: args[0], Type: sensorAddFlagType, Pin: sensorAddFlagPin, Pull: sensorAddFlagPull,
+			ActiveLow: sensorAddFlagActiveLow, Debounce: sensorAddFlagDebounce, Abort: sensorAddFlagAbort, KFactor: sensorAddFlagKFactor}
+		err := utils.PostRequest(daemonSocket+"/v1/sensors", &sensor)
+		if err != nil {
+			log.Fatal(err)
+		}
+	},
+}
+
+func init() {
+	sensorCmd.AddCommand(sensorAddCmd)
+	sensorAddCmd.PersistentFlags().StringVar(&sensorAddFlagType, "type", core.SensorRain, "type of the sensor: rain or flow")
+	sensorAddCmd.PersistentFlags().IntVar(&sensorAddFlagPin, "pin", 0, "GPIO input pin associated with this sensor")
+	sensorAddCmd.PersistentFlags().StringVar(&sensorAddFlagPull, "pull", "", "pull resistor of the input pin: up or down")
+	sensorAddCmd.PersistentFlags().BoolVar(&sensorAddFlagActiveLow, "active-low", false, "sensor is considered active when it's input pin is low")
+	sensorAddCmd.PersistentFlags().DurationVar(&sensorAddFlagDebounce, "debounce", 0, "time the input has to be stable to change the state of the sensor")
+	sensorAddCmd.PersistentFlags().BoolVar(&sensorAddFlagAbort, "abort", false, "stop the running programs when the sensor becomes active")
+	sensorAddCmd.PersistentFlags().Float64Var(&sensorAddFlagKFactor, "k-factor", 0, "pulses per liter of a flow sensor")
+}
@@ -0,0 +1,32 @@
+package cmd
+
+import (
+	"log"
+	"os"
+
+	"github.com/peter-vaczi/sprinkler/utils"
+	"github.com/spf13/cobra"
+)
+
+// sensorDelCmd represents the sensor del command
+var sensorDelCmd = &cobra.Command{
+	Use:   "del <name>",
+	Short: "Delete a sensor",
+	Long:  `Delete a sensor`,
+	Run: func(cmd *cobra.Command, args []string) {
+
+		if len(args) != 1 {
+			cmd.Usage()
+			os.Exit(-1)
+		}
+
+		err := utils.DeleteRequest(daemonSocket + "/v1/sensors/" + args[0])
+		if err != nil {
+			log.Fatal(err)
+		}
+	},
+}
+
+func init() {
+	sensorCmd.AddCommand(sensorDelCmd)
+}
@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/peter-vaczi/sprinkler/core"
+	"github.com/peter-vaczi/sprinkler/utils"
+)
+
+var sensorStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show status",
+	Long:  `Show status`,
+	Run: func(cmd *cobra.Command, args []string) {
+
+		var sensors core.Sensors
+
+		err := utils.GetRequest(daemonSocket+"/v1/sensors", &sensors)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		printSensors(sensors)
+	},
+}
+
+func printSensors(sensors core.Sensors) {
+	keys := make([]string, 0, len(sensors))
+	for k := range sensors {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	w := new(tabwriter.Writer)
+
+	w.Init(os.Stdout, 5, 0, 1, ' ', 0)
+	fmt.Fprintln(w, "NAME\tTYPE\tPIN\tSTATUS\t")
+
+	for _, k := range keys {
+		status := "inactive"
+		if sensors[k].Active {
+			status = "active"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%d\t%s\t\n", sensors[k].Name, sensors[k].Type, sensors[k].Pin, status)
+	}
+
+	w.Flush()
+}
+
+func init() {
+	sensorCmd.AddCommand(sensorStatusCmd)
+}
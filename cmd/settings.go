@@ -0,0 +1,14 @@
+package cmd
+
+import "github.com/spf13/cobra"
+
+// settingsCmd represents the settings command
+var settingsCmd = &cobra.Command{
+	Use:   "settings",
+	Short: "Handle the controller settings",
+	Long:  `Handle the controller settings`,
+}
+
+func init() {
+	RootCmd.AddCommand(settingsCmd)
+}
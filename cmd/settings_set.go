@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"log"
+	"os"
+	"time"
+
+	"github.com/peter-vaczi/sprinkler/core"
+	"github.com/peter-vaczi/sprinkler/utils"
+	"github.com/spf13/cobra"
+)
+
+var settingsSetFlagMaxOpenValves int = -1
+var settingsSetFlagFlowTolerance int = -1
+var settingsSetFlagFlowAction string
+var settingsSetFlagFlowWindow time.Duration = -1
+var settingsSetFlagLeakFlow float64 = -1
+var settingsSetFlagLatitude float64
+var settingsSetFlagLongitude float64
+var settingsSetFlagBlackoutPolicy string
+var settingsSetFlagOverlapPolicy string
+var settingsSetFlagSerialize bool
+var settingsSetFlagCatchUp time.Duration = -1
+var settingsSetFlagResume bool
+
+// settingsSetCmd represents the settings set command
+var settingsSetCmd = &cobra.Command{
+	Use:   "set [flags]",
+	Short: "Set the controller settings",
+	Long:  `Set the controller settings`,
+	Run: func(cmd *cobra.Command, args []string) {
+
+		if len(args) != 0 {
+			cmd.Usage()
+			os.Exit(-1)
+		}
+
+		var set core.Settings
+
+		err := utils.GetRequest(daemonSocket+"/v1/settings", &set)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		if settingsSetFlagMaxOpenValves != -1 {
+			set.MaxOpenValves = settingsSetFlagMaxOpenValves
+		}
+		if settingsSetFlagFlowTolerance != -1 {
+			set.FlowTolerance = settingsSetFlagFlowTolerance
+		}
+		if 0 < len(settingsSetFlagFlowAction) {
+			set.FlowAction = settingsSetFlagFlowAction
+		}
+		if settingsSetFlagFlowWindow != -1 {
+			set.FlowWindow = settingsSetFlagFlowWindow
+		}
+		if settingsSetFlagLeakFlow != -1 {
+			set.LeakFlow = settingsSetFlagLeakFlow
+		}
+		if 0 < len(settingsSetFlagBlackoutPolicy) {
+			set.BlackoutPolicy = settingsSetFlagBlackoutPolicy
+		}
+		if 0 < len(settingsSetFlagOverlapPolicy) {
+			set.OverlapPolicy = settingsSetFlagOverlapPolicy
+		}
+		if cmd.Flags().Changed("serialize") {
+			set.Serialize = settingsSetFlagSerialize
+		}
+		if settingsSetFlagCatchUp != -1 {
+			set.CatchUp = settingsSetFlagCatchUp
+		}
+		if cmd.Flags().Changed("resume") {
+			set.Resume = settingsSetFlagResume
+		}
+		if cmd.Flags().Changed("latitude") {
+			set.Latitude = settingsSetFlagLatitude
+		}
+		if cmd.Flags().Changed("longitude") {
+			set.Longitude = settingsSetFlagLongitude
+		}
+
+		err = utils.PutRequest(daemonSocket+"/v1/settings", &set)
+		if err != nil {
+			log.Fatal(err)
+		}
+	},
+}
+
+func init() {
+	settingsCmd.AddCommand(settingsSetCmd)
+	settingsSetCmd.PersistentFlags().IntVar(&settingsSetFlagMaxOpenValves, "max-open-valves", -1, "number of valves allowed to be open at the same time, 0 means unlimited")
+	settingsSetCmd.PersistentFlags().IntVar(&settingsSetFlagFlowTolerance, "flow-tolerance", -1, "allowed difference of the measured and the expected flow in percent, 0 means 50%")
+	settingsSetCmd.PersistentFlags().StringVar(&settingsSetFlagFlowAction, "flow-action", "", "action if the flow is out of the tolerance: skip the zone or stop the program")
+	settingsSetCmd.PersistentFlags().DurationVar(&settingsSetFlagFlowWindow, "flow-window", -1, "time the flow is measured over, 0 means 1m")
+	settingsSetCmd.PersistentFlags().Float64Var(&settingsSetFlagLeakFlow, "leak-flow", -1, "flow in liters per minute tolerated while every zone is closed")
+	settingsSetCmd.PersistentFlags().StringVar(&settingsSetFlagOverlapPolicy, "overlap-policy", "", "programs started while running are skipped, queued or preempt the running ones: skip, queue or preempt")
+	settingsSetCmd.PersistentFlags().BoolVar(&settingsSetFlagSerialize, "serialize", false, "run only one program at a time")
+	settingsSetCmd.PersistentFlags().DurationVar(&settingsSetFlagCatchUp, "catch-up", -1, "runs missed while the daemon was down are started if they are late by at most this long, 0 means they are skipped")
+	settingsSetCmd.PersistentFlags().BoolVar(&settingsSetFlagResume, "resume", false, "resume the programs interrupted by the shutdown of the daemon")
+	settingsSetCmd.PersistentFlags().StringVar(&settingsSetFlagBlackoutPolicy, "blackout-policy", "", "runs inside a blackout window are skipped or shifted to its end: skip or shift")
+	settingsSetCmd.PersistentFlags().Float64Var(&settingsSetFlagLatitude, "latitude", 0, "latitude of the garden in degrees, north is positive")
+	settingsSetCmd.PersistentFlags().Float64Var(&settingsSetFlagLongitude, "longitude", 0, "longitude of the garden in degrees, east is positive")
+}
@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/peter-vaczi/sprinkler/core"
+	"github.com/peter-vaczi/sprinkler/utils"
+)
+
+var settingsShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Show the controller settings",
+	Long:  `Show the controller settings`,
+	Run: func(cmd *cobra.Command, args []string) {
+
+		var set core.Settings
+
+		err := utils.GetRequest(daemonSocket+"/v1/settings", &set)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		printSettings(&set)
+	},
+}
+
+func printSettings(set *core.Settings) {
+	w := new(tabwriter.Writer)
+
+	w.Init(os.Stdout, 5, 0, 1, ' ', 0)
+
+	maxOpen := "unlimited"
+	if set.MaxOpenValves > 0 {
+		maxOpen = fmt.Sprintf("%d", set.MaxOpenValves)
+	}
+	fmt.Fprintf(w, "max open valves:\t%s\t\n", maxOpen)
+
+	location := "not set"
+	if lat, lon, found := set.GetLocation(); found {
+		location = fmt.Sprintf("%.4f, %.4f", lat, lon)
+	}
+	fmt.Fprintf(w, "location:\t%s\t\n", location)
+
+	fmt.Fprintf(w, "blackout policy:\t%s\t\n", set.GetBlackoutPolicy())
+
+	policy, serialize := set.GetOverlapPolicy()
+	fmt.Fprintf(w, "overlap policy:\t%s\t\n", policy)
+	fmt.Fprintf(w, "serialize programs:\t%t\t\n", serialize)
+
+	catchUp := "disabled"
+	if set.CatchUp > 0 {
+		catchUp = set.CatchUp.String()
+	}
+	fmt.Fprintf(w, "catch-up window:\t%s\t\n", catchUp)
+
+	fmt.Fprintf(w, "resume interrupted programs:\t%t\t\n", set.GetResume())
+
+	mon := set.GetFlowMonitor()
+	fmt.Fprintf(w, "flow tolerance:\t%d%%\t\n", mon.Tolerance)
+	fmt.Fprintf(w, "flow action:\t%s\t\n", mon.Action)
+	fmt.Fprintf(w, "flow window:\t%s\t\n", mon.Window)
+	fmt.Fprintf(w, "leak flow:\t%.1f l/min\t\n", mon.LeakFlow)
+
+	w.Flush()
+}
+
+func init() {
+	settingsCmd.AddCommand(settingsShowCmd)
+}
@@ -0,0 +1,27 @@
+package cmd
+
+import (
+	"errors"
+	"time"
+)
+
+// timeLayouts are the accepted formats of the absolute times on the command line
+var timeLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+// parseTime parses an absolute time or a duration relative to now
+func parseTime(str string) (time.Time, error) {
+	if d, err := time.ParseDuration(str); err == nil {
+		return time.Now().Add(d), nil
+	}
+	for _, layout := range timeLayouts {
+		if t, err := time.ParseInLocation(layout, str, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, errors.New("invalid time: " + str)
+}
@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/peter-vaczi/sprinkler/core"
+	"github.com/peter-vaczi/sprinkler/utils"
+)
+
+var usageFlagFrom string
+var usageFlagTo string
+
+// usageCmd represents the usage command
+var usageCmd = &cobra.Command{
+	Use:   "usage",
+	Short: "Show the water used by the zones",
+	Long: `Show the liters of water used by each zone and program between two days,
+e.g.: usage --from 2026-06-01 --to 2026-06-30
+Both days default to today.`,
+	Run: func(cmd *cobra.Command, args []string) {
+
+		if len(args) != 0 {
+			cmd.Usage()
+			os.Exit(-1)
+		}
+
+		query := url.Values{}
+		if usageFlagFrom != "" {
+			query.Set("from", usageFlagFrom)
+		}
+		if usageFlagTo != "" {
+			query.Set("to", usageFlagTo)
+		}
+
+		var report core.UsageReport
+		err := utils.GetRequest(daemonSocket+"/v1/usage?"+query.Encode(), &report)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		fmt.Printf("Water used from %s to %s\n\n", report.From, report.To)
+		printUsage("ZONE", report.Devices)
+		fmt.Println()
+		printUsage("PROGRAM", report.Programs)
+	},
+}
+
+func printUsage(title string, usage map[string]float64) {
+	keys := make([]string, 0, len(usage))
+	var total float64
+	for k, liters := range usage {
+		keys = append(keys, k)
+		total += liters
+	}
+	sort.Strings(keys)
+
+	w := new(tabwriter.Writer)
+
+	w.Init(os.Stdout, 5, 0, 1, ' ', 0)
+	fmt.Fprintf(w, "%s\tLITERS\t\n", title)
+
+	for _, k := range keys {
+		fmt.Fprintf(w, "%s\t%.1f\t\n", k, usage[k])
+	}
+	fmt.Fprintf(w, "TOTAL\t%.1f\t\n", total)
+
+	w.Flush()
+}
+
+func init() {
+	usageCmd.Flags().StringVar(&usageFlagFrom, "from", "", "first day of the report (YYYY-MM-DD)")
+	usageCmd.Flags().StringVar(&usageFlagTo, "to", "", "last day of the report (YYYY-MM-DD)")
+	RootCmd.AddCommand(usageCmd)
+}
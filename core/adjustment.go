@@ -0,0 +1,60 @@
+package core
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+var (
+	InvalidAdjustment = errors.New("Invalid seasonal adjustment")
+)
+
+// Adjustment maps the months (jan, feb, ...) to a watering scale in percent,
+// durations are not scaled in the months missing from the table
+type Adjustment map[string]int
+
+// MonthKey returns the key of the month in an adjustment table
+func MonthKey(m time.Month) string {
+	return strings.ToLower(m.String()[:3])
+}
+
+// Percent returns the scale of the month of t if it is set in the table
+func (a Adjustment) Percent(t time.Time) (int, bool) {
+	pct, found := a[MonthKey(t.Month())]
+	return pct, found
+}
+
+func (a Adjustment) validate() error {
+	for key, pct := range a {
+		if !validMonthKey(key) || pct < 0 {
+			return InvalidAdjustment
+		}
+	}
+	return nil
+}
+
+func (a Adjustment) copy() Adjustment {
+	if a == nil {
+		return nil
+	}
+	c := make(Adjustment, len(a))
+	for key, pct := range a {
+		c[key] = pct
+	}
+	return c
+}
+
+func validMonthKey(key string) bool {
+	for m := time.January; m <= time.December; m++ {
+		if key == MonthKey(m) {
+			return true
+		}
+	}
+	return false
+}
+
+// scaleDuration returns the duration scaled by pct percent
+func scaleDuration(d time.Duration, pct int) time.Duration {
+	return d * time.Duration(pct) / 100
+}
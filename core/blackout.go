@@ -0,0 +1,82 @@
+package core
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+var (
+	InvalidBlackout = errors.New("Invalid blackout")
+)
+
+// policies of the schedules starting inside a blackout window
+const (
+	BlackoutSkip  = "skip"
+	BlackoutShift = "shift"
+)
+
+// Blackout is a daily time window the schedules must not start programs in,
+// the window is restricted to the given weekdays if Days is not empty, it
+// ends on the next day if To is not later than From
+type Blackout struct {
+	Name string   `json:"name"`
+	Days []string `json:"days,omitempty"`
+	From string   `json:"from"`
+	To   string   `json:"to"`
+}
+
+func (b *Blackout) validate() error {
+	if b.Name == "" {
+		return InvalidBlackout
+	}
+	for _, day := range b.Days {
+		if _, found := weekdayNames[strings.ToLower(day)]; !found {
+			return InvalidBlackout
+		}
+	}
+	if _, err := time.Parse("15:04", b.From); err != nil {
+		return InvalidBlackout
+	}
+	if _, err := time.Parse("15:04", b.To); err != nil {
+		return InvalidBlackout
+	}
+	return nil
+}
+
+// end returns the end of the window if the time t is inside of it
+func (b *Blackout) end(t time.Time) (time.Time, bool) {
+	from, err := time.Parse("15:04", b.From)
+	if err != nil {
+		return time.Time{}, false
+	}
+	to, err := time.Parse("15:04", b.To)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	// the window may have started on the previous day
+	for offset := -1; offset <= 0; offset++ {
+		start := time.Date(t.Year(), t.Month(), t.Day()+offset, from.Hour(), from.Minute(), 0, 0, t.Location())
+		end := time.Date(start.Year(), start.Month(), start.Day(), to.Hour(), to.Minute(), 0, 0, t.Location())
+		if !end.After(start) {
+			end = end.AddDate(0, 0, 1)
+		}
+		if b.onDay(start.Weekday()) && !t.Before(start) && t.Before(end) {
+			return end, true
+		}
+	}
+	return time.Time{}, false
+}
+
+func (b *Blackout) onDay(day time.Weekday) bool {
+	if len(b.Days) == 0 {
+		return true
+	}
+	for _, name := range b.Days {
+		if weekdayNames[strings.ToLower(name)] == day {
+			return true
+		}
+	}
+	return false
+}
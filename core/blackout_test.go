@@ -0,0 +1,111 @@
+package core_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/peter-vaczi/sprinkler/core"
+	"github.com/stretchr/testify/assert"
+)
+
+// soonSchedule runs after the given duration
+type soonSchedule struct {
+	d time.Duration
+}
+
+func (s soonSchedule) Next(t time.Time) time.Time {
+	return t.Add(s.d)
+}
+
+func TestBlackouts(t *testing.T) {
+	set := core.NewSettings()
+	for _, b := range []*core.Blackout{{Name: "b", From: "10", To: "18:00"}, {Name: "b", Days: []string{"someday"}, From: "10:00", To: "18:00"}, {From: "10:00", To: "18:00"}} {
+		assert.Equal(t, core.InvalidBlackout, set.SetBlackouts([]*core.Blackout{b}))
+	}
+	b := &core.Blackout{Name: "b", From: "10:00", To: "18:00"}
+	assert.Equal(t, core.InvalidBlackout, set.SetBlackouts([]*core.Blackout{b, b}))
+	assert.Equal(t, core.InvalidSettings, set.Set(&core.Settings{BlackoutPolicy: "ignore"}))
+
+	assert.Nil(t, set.SetBlackouts([]*core.Blackout{
+		{Name: "day", From: "10:00", To: "18:00"},
+		{Name: "party", Days: []string{"sun"}, From: "08:00", To: "13:00"},
+		{Name: "night", From: "22:00", To: "06:00"}}))
+	assert.Len(t, set.GetBlackouts(), 3)
+	assert.Equal(t, core.BlackoutSkip, set.GetBlackoutPolicy())
+
+	// Oct 18 2026 is a Sunday, the party is followed by the daily window
+	at := func(day, hour, min int) time.Time {
+		return time.Date(2026, 10, day, hour, min, 0, 0, time.Local)
+	}
+	name, end, found := set.GetBlackout(at(18, 9, 0))
+	assert.True(t, found)
+	assert.Equal(t, "party", name)
+	assert.Equal(t, at(18, 18, 0), end)
+
+	_, _, found = set.GetBlackout(at(19, 9, 0))
+	assert.False(t, found)
+	_, _, found = set.GetBlackout(at(19, 18, 0))
+	assert.False(t, found)
+
+	name, end, found = set.GetBlackout(at(19, 3, 0))
+	assert.True(t, found)
+	assert.Equal(t, "night", name)
+	assert.Equal(t, at(19, 6, 0), end)
+	_, end, _ = set.GetBlackout(at(19, 23, 0))
+	assert.Equal(t, at(20, 6, 0), end)
+}
+
+func TestScheduleActiveRange(t *testing.T) {
+	scheds := core.NewSchedules()
+	assert.Equal(t, core.InvalidActiveRange, scheds.Add(&core.Schedule{Name: "sc1", Spec: "0 6 * * *", ActiveFrom: "04/15"}))
+	assert.Equal(t, core.InvalidActiveRange, scheds.Add(&core.Schedule{Name: "sc1", Spec: "0 6 * * *", ActiveTo: "13-01"}))
+
+	now := time.Now()
+	from := now.AddDate(0, 0, 10)
+	s1 := &core.Schedule{Name: "sc1", Spec: "0 6 * * *", ActiveFrom: from.Format("01-02"), ActiveTo: now.AddDate(0, 0, 20).Format("01-02")}
+	assert.Nil(t, scheds.Add(s1))
+	assert.Equal(t, time.Date(from.Year(), from.Month(), from.Day(), 6, 0, 0, 0, time.Local), s1.GetNext())
+
+	// the range spans most of the year except the next 10 days
+	assert.Nil(t, s1.SetActive(from.Format("01-02"), now.AddDate(0, 0, -1).Format("01-02")))
+	assert.Equal(t, time.Date(from.Year(), from.Month(), from.Day(), 6, 0, 0, 0, time.Local), s1.GetNext())
+
+	assert.Nil(t, s1.SetActive("", ""))
+	assert.True(t, s1.GetNext().Before(now.Add(25*time.Hour)))
+}
+
+func TestScheduleBlackout(t *testing.T) {
+	gpioStub := NewGpioStub()
+	core.InitGpio(gpioStub)
+	data := core.NewData()
+	d1 := &core.Device{Name: "dev1", Pin: 1}
+	assert.Nil(t, data.Devices.Add(d1))
+	p := &core.Program{Name: "pr1"}
+	assert.Nil(t, p.AddDevice(d1, time.Second))
+	assert.Nil(t, data.Programs.Add(p))
+
+	now := time.Now()
+	end := now.Add(2 * time.Hour).Truncate(time.Minute)
+	blackout := &core.Blackout{Name: "noon", From: now.Add(-time.Hour).Format("15:04"), To: end.Format("15:04")}
+	assert.Nil(t, data.Settings.SetBlackouts([]*core.Blackout{blackout}))
+
+	// the run inside the blackout is skipped
+	s1 := &core.Schedule{Name: "sc1", Spec: "* * * * *", Program: p}
+	assert.Nil(t, data.Schedules.Add(s1))
+	s1.Sched = soonSchedule{100 * time.Millisecond}
+	assert.Nil(t, s1.GetSkipped())
+	s1.Enable()
+	time.Sleep(150 * time.Millisecond)
+	s1.Disable()
+	assert.False(t, d1.IsOn())
+	if assert.NotNil(t, s1.GetSkipped()) {
+		assert.Contains(t, s1.GetSkipped().Reason, "blackout noon")
+	}
+
+	// or shifted to the end of the blackout
+	assert.Nil(t, data.Settings.Set(&core.Settings{Blackouts: []*core.Blackout{blackout}, BlackoutPolicy: core.BlackoutShift}))
+	assert.Equal(t, end.Year(), s1.GetNext().Year())
+	assert.True(t, s1.GetNext().Equal(time.Date(end.Year(), end.Month(), end.Day(), end.Hour(), end.Minute(), 0, 0, time.Local)))
+
+	core.NewData()
+}
@@ -0,0 +1,96 @@
+package core
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// maxCalendarRuns is the number of runs listed at most for a schedule
+const maxCalendarRuns = 1000
+
+// CalendarEntry is an expected program start of a schedule with the on and
+// off times of its zones
+type CalendarEntry struct {
+	Schedule string     `json:"schedule"`
+	Program  string     `json:"program"`
+	Start    time.Time  `json:"start"`
+	End      time.Time  `json:"end"`
+	Zones    []*ZoneRun `json:"zones"`
+	Skipped  string     `json:"skipped,omitempty"`
+	Overlaps []string   `json:"overlaps,omitempty"`
+}
+
+// ZoneRun is a zone switched on and off by a program run
+type ZoneRun struct {
+	Device string    `json:"device"`
+	On     time.Time `json:"on"`
+	Off    time.Time `json:"off"`
+}
+
+// Calendar returns the program starts of the enabled schedules between from
+// and to ordered by their start, the runs expected to be skipped are marked
+// with the reason, the overlapping runs list the schedules they overlap with
+func (s *Schedules) Calendar(from, to time.Time) []*CalendarEntry {
+	entries := []*CalendarEntry{}
+	for _, sc := range s.List() {
+		if !sc.IsEnabled() || sc.Program == nil {
+			continue
+		}
+
+		skips := sc.GetSkipNext()
+		next := sc.next(from.Add(-time.Second))
+		for i := 0; i < maxCalendarRuns && !next.IsZero() && !next.After(to); i++ {
+			entry := sc.calendarEntry(next)
+			if _, paused := sc.GetPaused(next); !paused && skips > 0 {
+				entry.Skipped = "skipped on request"
+				skips--
+			}
+			entries = append(entries, entry)
+			next = sc.next(next)
+		}
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Start.Equal(entries[j].Start) {
+			return entries[i].Schedule < entries[j].Schedule
+		}
+		return entries[i].Start.Before(entries[j].Start)
+	})
+
+	for i, a := range entries {
+		if a.Skipped != "" {
+			continue
+		}
+		for j, b := range entries {
+			if i != j && b.Skipped == "" && a.Start.Before(b.End) && b.Start.Before(a.End) {
+				a.Overlaps = append(a.Overlaps, b.Schedule)
+			}
+		}
+	}
+	return entries
+}
+
+// calendarEntry returns the run of the schedule started at the time t
+func (s *Schedule) calendarEntry(t time.Time) *CalendarEntry {
+	entry := &CalendarEntry{Schedule: s.Name, Program: s.Program.Name, Start: t, End: t, Zones: []*ZoneRun{}}
+	for _, step := range s.Program.timelineAt(t) {
+		on := t.Add(step.Start)
+		off := t.Add(step.end())
+		for _, dev := range step.Devices {
+			entry.Zones = append(entry.Zones, &ZoneRun{Device: dev, On: on, Off: off})
+		}
+		if off.After(entry.End) {
+			entry.End = off
+		}
+	}
+
+	if until, paused := s.GetPaused(t); paused {
+		entry.Skipped = fmt.Sprintf("paused until %s", until.Format(time.RFC1123))
+	} else if until, delayed := ctrl.rainDelay(t); delayed {
+		entry.Skipped = fmt.Sprintf("rain delay until %s", until.Format(time.RFC1123))
+	} else if name, _, found := ctrl.blackout(t); found {
+		entry.Skipped = fmt.Sprintf("blackout %s", name)
+	}
+	return entry
+}
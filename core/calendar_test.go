@@ -0,0 +1,72 @@
+package core_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/peter-vaczi/sprinkler/core"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCalendar(t *testing.T) {
+	gpioStub := NewGpioStub()
+	core.InitGpio(gpioStub)
+	data := core.NewData()
+	d1 := &core.Device{Name: "dev1", Pin: 1}
+	d2 := &core.Device{Name: "dev2", Pin: 2}
+	d3 := &core.Device{Name: "dev3", Pin: 3}
+	for _, d := range []*core.Device{d1, d2, d3} {
+		assert.Nil(t, data.Devices.Add(d))
+	}
+	p1 := &core.Program{Name: "pr1"}
+	assert.Nil(t, p1.AddDevice(d1, 10*time.Minute))
+	assert.Nil(t, p1.AddDevice(d2, 5*time.Minute))
+	p2 := &core.Program{Name: "pr2"}
+	assert.Nil(t, p2.AddDevice(d3, 10*time.Minute))
+	assert.Nil(t, data.Programs.Add(p1))
+	assert.Nil(t, data.Programs.Add(p2))
+
+	assert.Nil(t, data.Schedules.Add(&core.Schedule{Name: "sc1", Spec: "0 6 * * *", Program: p1, Enabled: true}))
+	assert.Nil(t, data.Schedules.Add(&core.Schedule{Name: "sc2", Spec: "10 6 * * *", Program: p2, Enabled: true}))
+	assert.Nil(t, data.Schedules.Add(&core.Schedule{Name: "sc3", Spec: "0 8 * * *", Program: p2}))
+
+	at := func(day, hour, min, sec int) time.Time {
+		return time.Date(2026, 6, day, hour, min, sec, 0, time.Local)
+	}
+	data.Settings.SetRainDelay(at(2, 0, 0, 0))
+
+	entries := data.Schedules.Calendar(at(1, 0, 0, 0), at(2, 23, 59, 59))
+	assert.Len(t, entries, 4)
+
+	// the rain delay skips the runs of the first day
+	assert.Equal(t, "sc1", entries[0].Schedule)
+	assert.Equal(t, "pr1", entries[0].Program)
+	assert.Contains(t, entries[0].Skipped, "rain delay")
+	assert.Empty(t, entries[0].Overlaps)
+	assert.Equal(t, "sc2", entries[1].Schedule)
+	assert.NotEmpty(t, entries[1].Skipped)
+
+	e := entries[2]
+	assert.Equal(t, "sc1", e.Schedule)
+	assert.Equal(t, at(2, 6, 0, 0), e.Start)
+	assert.Equal(t, at(2, 6, 15, 1), e.End)
+	assert.Equal(t, []*core.ZoneRun{
+		{Device: "dev1", On: at(2, 6, 0, 0), Off: at(2, 6, 10, 0)},
+		{Device: "dev2", On: at(2, 6, 10, 1), Off: at(2, 6, 15, 1)}}, e.Zones)
+	assert.Empty(t, e.Skipped)
+	assert.Equal(t, []string{"sc2"}, e.Overlaps)
+
+	assert.Equal(t, "sc2", entries[3].Schedule)
+	assert.Equal(t, at(2, 6, 20, 0), entries[3].End)
+	assert.Equal(t, []string{"sc1"}, entries[3].Overlaps)
+
+	// the zone closes after the open settle time and the duration
+	assert.Nil(t, d3.SetSettle(30*time.Second, 0))
+	entries = data.Schedules.Calendar(at(2, 0, 0, 0), at(2, 23, 59, 59))
+	assert.Len(t, entries, 2)
+	e = entries[1]
+	assert.Equal(t, at(2, 6, 20, 30), e.End)
+	assert.Equal(t, []*core.ZoneRun{{Device: "dev3", On: at(2, 6, 10, 0), Off: at(2, 6, 20, 30)}}, e.Zones)
+
+	core.NewData()
+}
@@ -0,0 +1,499 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+var (
+	TooManyValves = errors.New("Too many valves to open at once")
+)
+
+// controller coordinates the devices switched by the running programs,
+// it limits the number of open valves, switches the master devices on
+// before the first zone opens and off after the last zone is closed, the
+// water measured by the flow sensors is attributed to the open zones
+type controller struct {
+	data       *Data
+	dataM      sync.Mutex
+	open       int
+	opened     map[*Device]int
+	owners     map[*Device]string
+	volumes    map[*Device]float64
+	closed     time.Time
+	leakStart  time.Time
+	leakLiters float64
+	leaking    bool
+	lag        *time.Timer
+	changed    chan struct{}
+	m          sync.Mutex
+}
+
+var ctrl = &controller{opened: make(map[*Device]int), owners: make(map[*Device]string), volumes: make(map[*Device]float64), changed: make(chan struct{})}
+
+// openZones waits until the given zones may be opened without exceeding the
+// valve limit, switches on the master devices, waits for their lead time and
+// then switches on the given zones, owner is the name of the program opening
+// the zones or empty for the manual runs
+func (c *controller) openZones(ctx context.Context, owner string, zones ...*Device) error {
+	return c.switchOn(ctx, owner, true, zones...)
+}
+
+// tryOpenZones opens the given zones like openZones, but it returns
+// TooManyValves instead of waiting if the valve limit is reached, a program
+// overlapping its steps closes its previous zones then
+func (c *controller) tryOpenZones(ctx context.Context, owner string, zones ...*Device) error {
+	return c.switchOn(ctx, owner, false, zones...)
+}
+
+func (c *controller) switchOn(ctx context.Context, owner string, wait bool, zones ...*Device) error {
+	c.m.Lock()
+	if owner == "" && c.programZone(zones...) {
+		c.m.Unlock()
+		return DeviceInUse
+	}
+	for limit := c.maxOpenValves(); limit > 0 && c.open+len(zones) > limit; limit = c.maxOpenValves() {
+		if len(zones) > limit || !wait {
+			c.m.Unlock()
+			return TooManyValves
+		}
+
+		changed := c.changed
+		c.m.Unlock()
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-changed:
+		}
+		c.m.Lock()
+		if owner == "" && c.programZone(zones...) {
+			// a program opened the zone while the manual run was waiting
+			c.m.Unlock()
+			return DeviceInUse
+		}
+	}
+
+	if c.lag != nil {
+		c.lag.Stop()
+		c.lag = nil
+	}
+	c.open += len(zones)
+	for _, dev := range zones {
+		c.opened[dev]++
+		c.owners[dev] = owner
+	}
+
+	var lead time.Duration
+	for _, dev := range c.masters() {
+		if !dev.IsOn() {
+			dev.TurnOn()
+			if dev.Lead > lead {
+				lead = dev.Lead
+			}
+		}
+	}
+	c.m.Unlock()
+
+	if !sleep(ctx, lead) {
+		c.closeZones(zones...)
+		return ctx.Err()
+	}
+
+	c.m.Lock()
+	c.leaking = false
+	c.m.Unlock()
+
+	for _, dev := range zones {
+		dev.TurnOn()
+	}
+	return nil
+}
+
+// programZone returns true if any of the given zones is kept open by a
+// program, the caller holds the lock
+func (c *controller) programZone(zones ...*Device) bool {
+	for _, dev := range zones {
+		if c.opened[dev] > 0 && c.owners[dev] != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// manualRunAllowed returns DeviceInUse if a program keeps the zone open and,
+// with serialized programs, OtherProgramRunning if any program is running
+func (c *controller) manualRunAllowed(dev *Device) error {
+	c.m.Lock()
+	inUse := c.programZone(dev)
+	c.m.Unlock()
+	if inUse {
+		return DeviceInUse
+	}
+	if _, serial := c.overlapPolicy(); serial && len(c.runningPrograms()) > 0 {
+		return OtherProgramRunning
+	}
+	return nil
+}
+
+// closeZones switches off the given zones, the master devices are switched
+// off after their lag time if no other zones are open, a zone closed already
+// is not released again
+func (c *controller) closeZones(zones ...*Device) {
+	for _, dev := range zones {
+		dev.TurnOff()
+	}
+
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	for _, dev := range zones {
+		c.open -= c.opened[dev]
+		delete(c.opened, dev)
+		delete(c.owners, dev)
+	}
+	c.wakeUp()
+	if c.open > 0 {
+		return
+	}
+	c.closed = time.Now()
+
+	var lag time.Duration
+	for _, dev := range c.masters() {
+		if dev.Lag > lag {
+			lag = dev.Lag
+		}
+	}
+	if lag == 0 {
+		c.mastersOff()
+		return
+	}
+
+	var t *time.Timer
+	t = time.AfterFunc(lag, func() {
+		c.m.Lock()
+		defer c.m.Unlock()
+
+		if c.lag == t {
+			c.lag = nil
+			c.mastersOff()
+		}
+	})
+	c.lag = t
+}
+
+// flow accounts the water measured by a flow sensor, it is shared equally
+// by the open zones, the zones switched on by hand have no owner, the flow
+// is a leak if every zone is closed for at least the measuring window
+func (c *controller) flow(liters float64) {
+	data := c.getData()
+	if data == nil {
+		return
+	}
+	mon := data.Settings.GetFlowMonitor()
+
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	var open []*Device
+	for _, dev := range data.Devices.List() {
+		if !dev.IsMaster() && dev.IsOn() {
+			open = append(open, dev)
+		}
+	}
+	now := time.Now()
+	if len(open) != 0 {
+		// the zones switched on by hand are closed without the controller
+		c.closed = now
+	}
+	for _, dev := range open {
+		share := liters / float64(len(open))
+		c.volumes[dev] += share
+		data.Usage.Add(now, share, dev.Name, c.owners[dev])
+	}
+
+	if len(open) != 0 || c.open != 0 || now.Sub(c.closed) < mon.Window {
+		return
+	}
+	if c.leakStart.IsZero() || now.Sub(c.leakStart) > mon.Window {
+		c.leakStart = now
+		c.leakLiters = 0
+	}
+	c.leakLiters += liters
+	if !c.leaking && c.leakLiters/mon.Window.Minutes() > mon.LeakFlow {
+		c.leaking = true
+		data.Events.Add(&Event{Type: EventLeak,
+			Message: fmt.Sprintf("leak detected, %.1f liters flowed in %s while every zone is closed", c.leakLiters, mon.Window)})
+	}
+}
+
+// volume returns the liters of water measured by the flow sensors while the
+// given zones were open, the zones share the flow with the other open zones
+func (c *controller) volume(zones ...*Device) float64 {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	var liters float64
+	for _, dev := range zones {
+		liters += c.volumes[dev]
+	}
+	return liters
+}
+
+// metered returns true if the water flow is measured by a flow sensor
+func (c *controller) metered() bool {
+	data := c.getData()
+	if data == nil {
+		return false
+	}
+	return data.Sensors.metered()
+}
+
+// flowMonitor returns the flow monitoring settings
+func (c *controller) flowMonitor() FlowMonitor {
+	data := c.getData()
+	if data == nil {
+		return NewSettings().GetFlowMonitor()
+	}
+	return data.Settings.GetFlowMonitor()
+}
+
+// cutOff closes the device switched off by its watchdog, the zone is released
+// no matter who opened it
+func (c *controller) cutOff(dev *Device, maxOn time.Duration) {
+	c.event(&Event{Type: EventMaxOn, Device: dev.Name,
+		Message: fmt.Sprintf("device %s is on for more than %s, switching it off", dev.Name, maxOn)})
+	c.closeZones(dev)
+}
+
+// event records a fault detected by the controller
+func (c *controller) event(event *Event) {
+	data := c.getData()
+	if data == nil {
+		log.Printf("ALERT: %s", event.Message)
+		return
+	}
+	data.Events.Add(event)
+}
+
+// notify wakes up the programs waiting for free valves
+func (c *controller) notify() {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	c.wakeUp()
+}
+
+func (c *controller) wakeUp() {
+	close(c.changed)
+	c.changed = make(chan struct{})
+}
+
+// setData sets the data the controller works on
+func (c *controller) setData(data *Data) {
+	c.dataM.Lock()
+	defer c.dataM.Unlock()
+
+	c.data = data
+}
+
+func (c *controller) getData() *Data {
+	c.dataM.Lock()
+	defer c.dataM.Unlock()
+
+	return c.data
+}
+
+func (c *controller) maxOpenValves() int {
+	data := c.getData()
+	if data == nil {
+		return 0
+	}
+	return data.Settings.GetMaxOpenValves()
+}
+
+// scale returns the controller wide seasonal adjustment for the time t
+func (c *controller) scale(t time.Time) int {
+	data := c.getData()
+	if data == nil {
+		return 100
+	}
+	if pct, found := data.Settings.GetAdjustment().Percent(t); found {
+		return pct
+	}
+	return 100
+}
+
+// rainDelay returns the end of the rain delay if it is active at the time t
+func (c *controller) rainDelay(t time.Time) (time.Time, bool) {
+	data := c.getData()
+	if data == nil {
+		return time.Time{}, false
+	}
+	return data.Settings.GetRainDelay(t)
+}
+
+// blockingSensor returns the name of an active rain sensor
+func (c *controller) blockingSensor() (string, bool) {
+	data := c.getData()
+	if data == nil {
+		return "", false
+	}
+	return data.Sensors.blocking()
+}
+
+// location returns the latitude and longitude of the controller
+func (c *controller) location() (float64, float64, bool) {
+	data := c.getData()
+	if data == nil {
+		return 0, 0, false
+	}
+	return data.Settings.GetLocation()
+}
+
+// blackout returns the name and the end of the blackout window the time t
+// is inside of
+func (c *controller) blackout(t time.Time) (string, time.Time, bool) {
+	data := c.getData()
+	if data == nil {
+		return "", time.Time{}, false
+	}
+	return data.Settings.GetBlackout(t)
+}
+
+func (c *controller) blackoutPolicy() string {
+	data := c.getData()
+	if data == nil {
+		return BlackoutSkip
+	}
+	return data.Settings.GetBlackoutPolicy()
+}
+
+func (c *controller) catchUp() time.Duration {
+	data := c.getData()
+	if data == nil {
+		return 0
+	}
+	return data.Settings.GetCatchUp()
+}
+
+// storeState saves the data loaded from the data file
+func (c *controller) storeState() {
+	if data := c.getData(); data != nil && data.loaded {
+		data.StoreState()
+	}
+}
+
+// delSchedule deletes the schedule of the given name
+func (c *controller) delSchedule(name string) {
+	if data := c.getData(); data != nil {
+		data.Schedules.Del(name)
+	}
+}
+
+// rearmSchedules restarts the timers of the enabled schedules
+func (c *controller) rearmSchedules() {
+	data := c.getData()
+	if data == nil {
+		return
+	}
+	for _, sc := range data.Schedules.List() {
+		if sc.IsEnabled() {
+			sc.Enable()
+		}
+	}
+}
+
+// overlapPolicy returns the policy of the programs started while they are
+// running and whether the programs are serialized
+func (c *controller) overlapPolicy() (string, bool) {
+	data := c.getData()
+	if data == nil {
+		return OverlapSkip, false
+	}
+	return data.Settings.GetOverlapPolicy()
+}
+
+// runningPrograms returns the programs currently running
+func (c *controller) runningPrograms() []*Program {
+	var running []*Program
+	data := c.getData()
+	if data == nil {
+		return running
+	}
+	for _, pr := range data.Programs.List() {
+		if pr.IsRunning() {
+			running = append(running, pr)
+		}
+	}
+	return running
+}
+
+// submit starts the program through the queue of the controller
+func (c *controller) submit(prog *Program, source string) (string, error) {
+	data := c.getData()
+	if data == nil {
+		prog.StartBy(source)
+		return Started, nil
+	}
+	return data.Queue.Submit(prog, source)
+}
+
+// programDone starts the queued programs allowed to run after a program
+// finished
+func (c *controller) programDone() {
+	if data := c.getData(); data != nil {
+		data.Queue.next()
+	}
+}
+
+func (c *controller) stopPrograms() {
+	if data := c.getData(); data != nil {
+		data.Queue.Clear()
+		data.Programs.StopAll()
+	}
+}
+
+func (c *controller) masters() []*Device {
+	var masters []*Device
+	data := c.getData()
+	if data == nil {
+		return masters
+	}
+	for _, dev := range data.Devices.List() {
+		if dev.IsMaster() {
+			masters = append(masters, dev)
+		}
+	}
+	return masters
+}
+
+func (c *controller) mastersOff() {
+	for _, dev := range c.masters() {
+		if dev.IsOn() {
+			dev.TurnOff()
+		}
+	}
+}
+
+// sleep waits for the given duration, it returns false if the context is
+// canceled in the meantime
+func sleep(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return ctx.Err() == nil
+	}
+
+	t := time.NewTimer(d)
+	select {
+	case <-ctx.Done():
+		if !t.Stop() {
+			<-t.C
+		}
+		return false
+	case <-t.C:
+		return true
+	}
+}
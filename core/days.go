@@ -0,0 +1,177 @@
+package core
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// day schedule kinds
+const (
+	Interval = "@interval"
+	OddDays  = "@odd"
+	EvenDays = "@even"
+	Weekdays = "@weekdays"
+)
+
+// options of the odd and even day schedules
+const (
+	Skip31st  = "skip-31st"
+	SkipFeb29 = "skip-feb29"
+)
+
+// maxDays is how far the day schedules look for the next matching day
+const maxDays = 400
+
+var weekdayNames = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// daySchedule starts the program at the given time of the matching days
+type daySchedule struct {
+	match  func(day time.Time) bool
+	hour   int
+	minute int
+}
+
+// parseDaySpec parses the spec of a day schedule:
+//
+//	@interval <days> <first day> <HH:MM>, e.g. "@interval 3 2026-10-01 06:30"
+//	@odd|@even <HH:MM> [skip-31st] [skip-feb29], e.g. "@odd 06:30 skip-31st"
+//	@weekdays <days> <HH:MM>, e.g. "@weekdays mon,wed,fri 06:30"
+func parseDaySpec(spec string) (*daySchedule, error) {
+	fields := strings.Fields(spec)
+	if len(fields) < 2 {
+		return nil, InvalidSpec
+	}
+
+	var sc *daySchedule
+	var err error
+	switch fields[0] {
+	case Interval:
+		sc, err = parseInterval(fields[1:])
+	case OddDays, EvenDays:
+		sc, err = parseOddEven(fields[0] == OddDays, fields[1:])
+	case Weekdays:
+		sc, err = parseWeekdays(fields[1:])
+	default:
+		err = InvalidSpec
+	}
+	if err != nil {
+		return nil, err
+	}
+	return sc, nil
+}
+
+func parseInterval(fields []string) (*daySchedule, error) {
+	if len(fields) != 3 {
+		return nil, InvalidSpec
+	}
+	n, err := strconv.Atoi(fields[0])
+	if err != nil || n < 1 {
+		return nil, InvalidSpec
+	}
+	first, err := time.Parse(dayLayout, fields[1])
+	if err != nil {
+		return nil, InvalidSpec
+	}
+
+	sc, err := newDaySchedule(fields[2])
+	if err != nil {
+		return nil, err
+	}
+	sc.match = func(day time.Time) bool {
+		days := daysBetween(first, day)
+		return days >= 0 && days%n == 0
+	}
+	return sc, nil
+}
+
+func parseOddEven(odd bool, fields []string) (*daySchedule, error) {
+	sc, err := newDaySchedule(fields[0])
+	if err != nil {
+		return nil, err
+	}
+
+	var skip31st, skipFeb29 bool
+	for _, field := range fields[1:] {
+		switch field {
+		case Skip31st:
+			skip31st = true
+		case SkipFeb29:
+			skipFeb29 = true
+		default:
+			return nil, InvalidSpec
+		}
+	}
+
+	sc.match = func(day time.Time) bool {
+		if skip31st && day.Day() == 31 {
+			return false
+		}
+		if skipFeb29 && day.Month() == time.February && day.Day() == 29 {
+			return false
+		}
+		return (day.Day()%2 == 1) == odd
+	}
+	return sc, nil
+}
+
+func parseWeekdays(fields []string) (*daySchedule, error) {
+	if len(fields) != 2 {
+		return nil, InvalidSpec
+	}
+	days := make(map[time.Weekday]bool)
+	for _, name := range strings.Split(strings.ToLower(fields[0]), ",") {
+		day, found := weekdayNames[name]
+		if !found {
+			return nil, InvalidSpec
+		}
+		days[day] = true
+	}
+
+	sc, err := newDaySchedule(fields[1])
+	if err != nil {
+		return nil, err
+	}
+	sc.match = func(day time.Time) bool {
+		return days[day.Weekday()]
+	}
+	return sc, nil
+}
+
+// newDaySchedule returns a day schedule starting at the time of day given as
+// HH:MM
+func newDaySchedule(at string) (*daySchedule, error) {
+	t, err := time.Parse("15:04", at)
+	if err != nil {
+		return nil, InvalidSpec
+	}
+	return &daySchedule{hour: t.Hour(), minute: t.Minute()}, nil
+}
+
+// Next returns the first start after t, or the zero time if no day matches
+// in maxDays
+func (s *daySchedule) Next(t time.Time) time.Time {
+	for i := 0; i < maxDays; i++ {
+		day := time.Date(t.Year(), t.Month(), t.Day()+i, s.hour, s.minute, 0, 0, t.Location())
+		if s.match(day) && day.After(t) {
+			return day
+		}
+	}
+	return time.Time{}
+}
+
+// daysBetween returns the number of calendar days from the day of a to the
+// day of b
+func daysBetween(a, b time.Time) int {
+	da := time.Date(a.Year(), a.Month(), a.Day(), 0, 0, 0, 0, time.UTC)
+	db := time.Date(b.Year(), b.Month(), b.Day(), 0, 0, 0, 0, time.UTC)
+	return int(db.Sub(da).Hours() / 24)
+}
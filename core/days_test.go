@@ -0,0 +1,51 @@
+package core_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/peter-vaczi/sprinkler/core"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDaySchedules(t *testing.T) {
+	scheds := core.NewSchedules()
+	for _, spec := range []string{"@interval 0 2026-10-01 06:30", "@interval 3 2026-13-01 06:30", "@interval 3 2026-10-01",
+		"@odd 6 o'clock", "@even 06:30 skip-sundays", "@weekdays mon,someday 06:30", "@weekdays 06:30"} {
+		assert.Equal(t, core.InvalidSpec, scheds.Add(&core.Schedule{Name: "sc", Spec: spec}), spec)
+	}
+
+	day := func(month time.Month, day, hour, min int) time.Time {
+		return time.Date(2026, month, day, hour, min, 0, 0, time.Local)
+	}
+
+	// every 3 days from Oct 1, across the month boundary
+	s1 := &core.Schedule{Name: "sc1", Spec: "@interval 3 2026-10-01 06:30"}
+	assert.Nil(t, scheds.Add(s1))
+	assert.Equal(t, day(10, 1, 6, 30), s1.Sched.Next(day(9, 20, 0, 0)))
+	assert.Equal(t, day(10, 4, 6, 30), s1.Sched.Next(day(10, 1, 6, 30)))
+	assert.Equal(t, day(10, 31, 6, 30), s1.Sched.Next(day(10, 29, 0, 0)))
+	assert.Equal(t, day(11, 3, 6, 30), s1.Sched.Next(day(10, 31, 7, 0)))
+
+	// odd days, the 31st is skipped not to water two days in a row
+	s2 := &core.Schedule{Name: "sc2", Spec: "@odd 05:00 skip-31st"}
+	assert.Nil(t, scheds.Add(s2))
+	assert.Equal(t, day(10, 29, 5, 0), s2.Sched.Next(day(10, 28, 0, 0)))
+	assert.Equal(t, day(11, 1, 5, 0), s2.Sched.Next(day(10, 29, 6, 0)))
+
+	s3 := &core.Schedule{Name: "sc3", Spec: "@even 05:00"}
+	assert.Nil(t, scheds.Add(s3))
+	assert.Equal(t, day(10, 2, 5, 0), s3.Sched.Next(day(9, 30, 6, 0)))
+	assert.Nil(t, s3.SetSpec("@even 05:00 skip-feb29"))
+	assert.Equal(t, time.Date(2028, 3, 2, 5, 0, 0, 0, time.Local), s3.Sched.Next(time.Date(2028, 2, 28, 6, 0, 0, 0, time.Local)))
+
+	// Oct 18 2026 is a Sunday
+	s4 := &core.Schedule{Name: "sc4", Spec: "@weekdays Mon,fri 21:15"}
+	assert.Nil(t, scheds.Add(s4))
+	assert.Equal(t, day(10, 19, 21, 15), s4.Sched.Next(day(10, 18, 12, 0)))
+	assert.Equal(t, day(10, 23, 21, 15), s4.Sched.Next(day(10, 19, 21, 15)))
+
+	next := s4.GetNext()
+	assert.True(t, next.After(time.Now()))
+	assert.True(t, next.Before(time.Now().Add(7*24*time.Hour)))
+}
@@ -1,9 +1,12 @@
 package core
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
 	"log"
 	"sync"
+	"time"
 
 	"github.com/peter-vaczi/sprinkler/gpio"
 )
@@ -12,19 +15,40 @@ var (
 	AlreadyExists = errors.New("Already exists")
 	NotFound      = errors.New("Not found")
 	DeviceInUse   = errors.New("Device is in use")
+	InvalidRole   = errors.New("Invalid device role")
+	InvalidFlow   = errors.New("Invalid flow")
 	gpioLib       gpio.Gpio
 )
 
+// device roles, zones are switched by the programs, master valves and pumps
+// are switched on automatically while any of the zones is open
+const (
+	RoleZone   = "zone"
+	RoleMaster = "master"
+	RolePump   = "pump"
+)
+
 func InitGpio(g gpio.Gpio) {
 	gpioLib = g
 }
 
 type Device struct {
-	Name        string `json:"name"`
-	On          bool   `json:"on"`
-	SwitchOnLow bool   `json:"switch-on-low"`
-	Pin         int    `json:"pin"`
+	Name        string        `json:"name"`
+	On          bool          `json:"on"`
+	SwitchOnLow bool          `json:"switch-on-low"`
+	Pin         int           `json:"pin"`
+	Role        string        `json:"role,omitempty"`
+	Lead        time.Duration `json:"lead,omitempty"`
+	Lag         time.Duration `json:"lag,omitempty"`
+	MaxOn       time.Duration `json:"max-on,omitempty"`
+	Flow        float64       `json:"flow,omitempty"`
+	OpenSettle  time.Duration `json:"open-settle,omitempty"`
+	CloseSettle time.Duration `json:"close-settle,omitempty"`
 	pin         gpio.Pin
+	watchdog    *time.Timer
+	watchdogID  int
+	stopRun     context.CancelFunc
+	runID       int
 	m           sync.Mutex
 }
 
@@ -36,9 +60,21 @@ func NewDevices() *Devices {
 }
 
 func (d *Devices) Add(dev *Device) error {
+	mapsM.Lock()
+	defer mapsM.Unlock()
+
 	if _, exists := (*d)[dev.Name]; exists {
 		return AlreadyExists
 	}
+	if !validRole(dev.Role) {
+		return InvalidRole
+	}
+	if dev.Flow < 0 {
+		return InvalidFlow
+	}
+	if dev.OpenSettle < 0 || dev.CloseSettle < 0 {
+		return InvalidDuration
+	}
 
 	(*d)[dev.Name] = dev
 	dev.SetState(dev.Pin, dev.On)
@@ -47,6 +83,9 @@ func (d *Devices) Add(dev *Device) error {
 }
 
 func (d *Devices) Get(name string) (*Device, error) {
+	mapsM.RLock()
+	defer mapsM.RUnlock()
+
 	if dev, exists := (*d)[name]; exists {
 		return dev, nil
 	}
@@ -55,6 +94,9 @@ func (d *Devices) Get(name string) (*Device, error) {
 }
 
 func (d *Devices) Del(name string) error {
+	mapsM.Lock()
+	defer mapsM.Unlock()
+
 	if _, exists := (*d)[name]; exists {
 		delete(*d, name)
 		return nil
@@ -63,8 +105,49 @@ func (d *Devices) Del(name string) error {
 	return NotFound
 }
 
+// List returns the devices in no particular order
+func (d *Devices) List() []*Device {
+	mapsM.RLock()
+	defer mapsM.RUnlock()
+
+	devs := make([]*Device, 0, len(*d))
+	for _, dev := range *d {
+		devs = append(devs, dev)
+	}
+	return devs
+}
+
+// MarshalJSON encodes the devices while holding the lock of the maps
+func (d *Devices) MarshalJSON() ([]byte, error) {
+	type devices Devices
+
+	mapsM.RLock()
+	defer mapsM.RUnlock()
+
+	return json.Marshal((*devices)(d))
+}
+
 func (d *Devices) Set(name string, newDev *Device) error {
-	if dev, exists := (*d)[name]; exists {
+	if dev, err := d.Get(name); err == nil {
+		if newDev.Flow < 0 {
+			return InvalidFlow
+		}
+		if newDev.OpenSettle < 0 || newDev.CloseSettle < 0 {
+			return InvalidDuration
+		}
+		err := dev.SetRole(newDev.Role, newDev.Lead, newDev.Lag)
+		if err != nil {
+			return err
+		}
+		err = dev.SetSettle(newDev.OpenSettle, newDev.CloseSettle)
+		if err != nil {
+			return err
+		}
+		dev.SetMaxOn(newDev.MaxOn)
+		dev.SetFlow(newDev.Flow)
+		if !newDev.On {
+			dev.StopRun()
+		}
 		dev.SetState(newDev.Pin, newDev.On)
 		return nil
 	}
@@ -72,6 +155,15 @@ func (d *Devices) Set(name string, newDev *Device) error {
 	return NotFound
 }
 
+// StopAll cancels the manual runs and switches off their devices
+func (d *Devices) StopAll() {
+	for _, dev := range d.List() {
+		if dev.StopRun() {
+			dev.TurnOff()
+		}
+	}
+}
+
 func (d *Device) SetPin(pin int) {
 	d.m.Lock()
 	defer d.m.Unlock()
@@ -92,6 +184,14 @@ func (d *Device) TurnOn() {
 		d.pin.High()
 	}
 	log.Printf("device %s is on", d.Name)
+
+	if d.MaxOn > 0 && d.watchdog == nil {
+		d.watchdogID++
+		id := d.watchdogID
+		d.watchdog = time.AfterFunc(d.MaxOn, func() {
+			d.cutOff(id)
+		})
+	}
 }
 
 func (d *Device) TurnOff() {
@@ -105,6 +205,83 @@ func (d *Device) TurnOff() {
 		d.pin.Low()
 	}
 	log.Printf("device %s is off", d.Name)
+
+	if d.watchdog != nil {
+		d.watchdog.Stop()
+		d.watchdog = nil
+	}
+}
+
+// cutOff is called by the watchdog timer id when the device is on for longer
+// than its max on time, no matter who switched it on
+func (d *Device) cutOff(id int) {
+	d.m.Lock()
+	if d.watchdog == nil || d.watchdogID != id {
+		d.m.Unlock()
+		return
+	}
+	d.watchdog = nil
+	maxOn := d.MaxOn
+	d.m.Unlock()
+
+	ctrl.cutOff(d, maxOn)
+}
+
+// SetMaxOn sets the time after the device is switched off by its watchdog,
+// 0 disables the watchdog, the new value is used the next time the device
+// is switched on
+func (d *Device) SetMaxOn(maxOn time.Duration) {
+	d.m.Lock()
+	defer d.m.Unlock()
+
+	if d.MaxOn == maxOn {
+		return
+	}
+	d.MaxOn = maxOn
+	if d.watchdog != nil {
+		d.watchdog.Stop()
+		d.watchdog = nil
+	}
+}
+
+// SetFlow sets the expected flow of the device in liters per minute, 0 lets
+// the device learn it from its next metered step
+func (d *Device) SetFlow(flow float64) {
+	d.m.Lock()
+	defer d.m.Unlock()
+
+	d.Flow = flow
+}
+
+func (d *Device) GetFlow() float64 {
+	d.m.Lock()
+	defer d.m.Unlock()
+
+	return d.Flow
+}
+
+// SetSettle sets the time the valve of the device needs to open and to close
+// fully, the watering of a program starts after the open settle time and the
+// next valve is not opened before the close settle time elapses
+func (d *Device) SetSettle(open, close time.Duration) error {
+	if open < 0 || close < 0 {
+		return InvalidDuration
+	}
+
+	d.m.Lock()
+	defer d.m.Unlock()
+
+	d.OpenSettle = open
+	d.CloseSettle = close
+	return nil
+}
+
+// GetSettle returns the open and close settle times of the device
+func (d *Device) GetSettle() (time.Duration, time.Duration) {
+	d.m.Lock()
+	defer d.m.Unlock()
+
+	return d.OpenSettle, d.CloseSettle
 }
 
 func (d *Device) SetState(pin int, on bool) {
@@ -123,10 +300,115 @@ func (d *Device) SetOnIsLow(val bool) {
 	d.SwitchOnLow = val
 }
 
+// SetRole sets the role of the device, lead and lag are only used by master
+// devices: the master is switched on lead time before the first zone opens
+// and switched off lag time after the last zone is closed
+func (d *Device) SetRole(role string, lead, lag time.Duration) error {
+	if !validRole(role) {
+		return InvalidRole
+	}
+
+	d.m.Lock()
+	defer d.m.Unlock()
+
+	d.Role = role
+	d.Lead = lead
+	d.Lag = lag
+	return nil
+}
+
+func (d *Device) IsMaster() bool {
+	d.m.Lock()
+	defer d.m.Unlock()
+
+	return d.Role == RoleMaster || d.Role == RolePump
+}
+
+func validRole(role string) bool {
+	switch role {
+	case "", RoleZone, RoleMaster, RolePump:
+		return true
+	}
+	return false
+}
+
 func (d *Device) Init() {
 	d.SetState(d.Pin, d.On)
 }
 
+// Run opens the device for the given duration in the background, it waits
+// for a free valve and switches the master devices like the programs do, a
+// zone watered by a program or, with serialized programs, any zone while a
+// program is running is not opened
+func (d *Device) Run(duration time.Duration) error {
+	if d.IsMaster() {
+		return DeviceIsMaster
+	}
+	if duration <= 0 {
+		return InvalidDuration
+	}
+	if err := ctrl.manualRunAllowed(d); err != nil {
+		return err
+	}
+
+	d.StopRun()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	d.m.Lock()
+	d.runID++
+	id := d.runID
+	d.stopRun = cancel
+	d.m.Unlock()
+
+	go func() {
+		defer func() {
+			d.m.Lock()
+			if d.runID == id {
+				d.stopRun = nil
+			}
+			d.m.Unlock()
+			cancel()
+		}()
+
+		log.Printf("device %s is started manually for %s", d.Name, duration)
+		if err := ctrl.openZones(ctx, "", d); err != nil {
+			log.Printf("manual run of device %s is canceled: %v", d.Name, err)
+			return
+		}
+		if !sleep(ctx, duration) {
+			log.Printf("manual run of device %s is canceled", d.Name)
+		}
+		ctrl.closeZones(d)
+	}()
+
+	return nil
+}
+
+// StopRun cancels the manual run of the device, it returns false if the
+// device was not running
+func (d *Device) StopRun() bool {
+	d.m.Lock()
+	defer d.m.Unlock()
+
+	if d.stopRun == nil {
+		return false
+	}
+	d.stopRun()
+	d.stopRun = nil
+	return true
+}
+
+// MarshalJSON encodes the device while holding its lock, the watchdog may
+// switch it off at any time
+func (d *Device) MarshalJSON() ([]byte, error) {
+	type device Device
+
+	d.m.Lock()
+	defer d.m.Unlock()
+
+	return json.Marshal((*device)(d))
+}
+
 func (d *Device) IsOn() bool {
 	d.m.Lock()
 	defer d.m.Unlock()
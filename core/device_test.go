@@ -2,6 +2,7 @@ package core_test
 
 import (
 	"testing"
+	"time"
 
 	"github.com/peter-vaczi/sprinkler/core"
 	"github.com/stretchr/testify/assert"
@@ -90,3 +91,185 @@ func TestDevices(t *testing.T) {
 		assert.Empty(t, *devs)
 	}
 }
+
+func TestDeviceRole(t *testing.T) {
+	gpioStub := NewGpioStub()
+	core.InitGpio(gpioStub)
+	devs := core.NewDevices()
+
+	assert.Equal(t, core.InvalidRole, devs.Add(&core.Device{Name: "dev1", Role: "sprinkler"}))
+	assert.Nil(t, devs.Add(&core.Device{Name: "dev1"}))
+	d, _ := devs.Get("dev1")
+	assert.False(t, d.IsMaster())
+
+	assert.Equal(t, core.InvalidRole, devs.Set("dev1", &core.Device{Role: "sprinkler"}))
+	assert.Nil(t, devs.Set("dev1", &core.Device{Role: core.RolePump, Lead: time.Second, Lag: 2 * time.Second}))
+	assert.True(t, d.IsMaster())
+	assert.Equal(t, time.Second, d.Lead)
+	assert.Equal(t, 2*time.Second, d.Lag)
+
+	assert.Nil(t, d.SetRole(core.RoleZone, 0, 0))
+	assert.False(t, d.IsMaster())
+}
+
+func TestDeviceMaxOn(t *testing.T) {
+	gpioStub := NewGpioStub()
+	core.InitGpio(gpioStub)
+	devs := core.NewDevices()
+	d1 := &core.Device{Name: "dev1", Pin: 1, MaxOn: 200 * time.Millisecond}
+	assert.Nil(t, devs.Add(d1))
+
+	d1.TurnOn()
+	time.Sleep(100 * time.Millisecond)
+	assert.True(t, d1.IsOn())
+	time.Sleep(200 * time.Millisecond)
+	assert.False(t, d1.IsOn())
+
+	// switched off in time, the watchdog does nothing
+	d1.TurnOn()
+	time.Sleep(100 * time.Millisecond)
+	d1.TurnOff()
+	d1.TurnOn()
+	time.Sleep(150 * time.Millisecond)
+	assert.True(t, d1.IsOn())
+	d1.TurnOff()
+
+	// switched on through Set
+	assert.Nil(t, devs.Set("dev1", &core.Device{Pin: 1, On: true, MaxOn: 100 * time.Millisecond}))
+	assert.True(t, d1.IsOn())
+	time.Sleep(200 * time.Millisecond)
+	assert.False(t, d1.IsOn())
+
+	d1.SetMaxOn(0)
+	d1.TurnOn()
+	time.Sleep(200 * time.Millisecond)
+	assert.True(t, d1.IsOn())
+	d1.TurnOff()
+}
+
+func TestDeviceMaxOnRelease(t *testing.T) {
+	gpioStub := NewGpioStub()
+	core.InitGpio(gpioStub)
+	data := core.NewData()
+	d1 := &core.Device{Name: "dev1", Pin: 1, MaxOn: 200 * time.Millisecond}
+	d2 := &core.Device{Name: "dev2", Pin: 2}
+	m := &core.Device{Name: "master", Pin: 3, Role: core.RoleMaster}
+	assert.Nil(t, data.Devices.Add(d1))
+	assert.Nil(t, data.Devices.Add(d2))
+	assert.Nil(t, data.Devices.Add(m))
+	assert.Nil(t, data.Settings.Set(&core.Settings{MaxOpenValves: 1}))
+
+	// the cut off zone frees its valve and switches the master off
+	assert.Nil(t, d1.Run(time.Second))
+	time.Sleep(300 * time.Millisecond)
+	assert.False(t, d1.IsOn())
+	assert.False(t, m.IsOn())
+	events := data.Events.Get(time.Time{})
+	if assert.Equal(t, 1, len(events)) {
+		assert.Equal(t, core.EventMaxOn, events[0].Type)
+		assert.Equal(t, "dev1", events[0].Device)
+	}
+
+	assert.Nil(t, d2.Run(300*time.Millisecond))
+	time.Sleep(100 * time.Millisecond)
+	assert.True(t, d2.IsOn())
+	assert.True(t, m.IsOn())
+
+	// the end of the cut off run does not release the valve again
+	time.Sleep(700 * time.Millisecond)
+	assert.False(t, d2.IsOn())
+	assert.Nil(t, d1.Run(300*time.Millisecond))
+	time.Sleep(50 * time.Millisecond)
+	assert.Nil(t, d2.Run(300*time.Millisecond))
+	time.Sleep(50 * time.Millisecond)
+	assert.True(t, d1.IsOn())
+	assert.False(t, d2.IsOn())
+
+	data.Devices.StopAll()
+	time.Sleep(100 * time.Millisecond)
+	core.NewData()
+}
+
+func TestDeviceRun(t *testing.T) {
+	gpioStub := NewGpioStub()
+	core.InitGpio(gpioStub)
+	data := core.NewData()
+	d1 := &core.Device{Name: "dev1", Pin: 1}
+	d2 := &core.Device{Name: "dev2", Pin: 2}
+	m := &core.Device{Name: "master", Pin: 3, Role: core.RoleMaster}
+	assert.Nil(t, data.Devices.Add(d1))
+	assert.Nil(t, data.Devices.Add(d2))
+	assert.Nil(t, data.Devices.Add(m))
+
+	assert.Equal(t, core.DeviceIsMaster, m.Run(time.Second))
+	assert.Equal(t, core.InvalidDuration, d1.Run(0))
+
+	assert.Nil(t, d1.Run(300*time.Millisecond))
+	time.Sleep(100 * time.Millisecond)
+	assert.True(t, d1.IsOn())
+	assert.True(t, m.IsOn())
+	time.Sleep(300 * time.Millisecond)
+	assert.False(t, d1.IsOn())
+	assert.False(t, m.IsOn())
+
+	// the second run waits for the free valve
+	assert.Nil(t, data.Settings.Set(&core.Settings{MaxOpenValves: 1}))
+	assert.Nil(t, d1.Run(300*time.Millisecond))
+	time.Sleep(50 * time.Millisecond)
+	assert.Nil(t, d2.Run(300*time.Millisecond))
+	time.Sleep(50 * time.Millisecond)
+	assert.True(t, d1.IsOn())
+	assert.False(t, d2.IsOn())
+	time.Sleep(300 * time.Millisecond)
+	assert.False(t, d1.IsOn())
+	assert.True(t, d2.IsOn())
+
+	// switching the device off cancels the run
+	assert.Nil(t, data.Devices.Set("dev2", &core.Device{Pin: 2, On: false}))
+	assert.False(t, d2.IsOn())
+	assert.False(t, d2.StopRun())
+
+	assert.Nil(t, d1.Run(time.Second))
+	time.Sleep(100 * time.Millisecond)
+	data.Devices.StopAll()
+	assert.False(t, d1.IsOn())
+
+	time.Sleep(100 * time.Millisecond)
+	core.NewData()
+}
+
+func TestDeviceRunProgramZone(t *testing.T) {
+	gpioStub := NewGpioStub()
+	core.InitGpio(gpioStub)
+	data := core.NewData()
+	d1 := &core.Device{Name: "dev1", Pin: 1}
+	d2 := &core.Device{Name: "dev2", Pin: 2}
+	assert.Nil(t, data.Devices.Add(d1))
+	assert.Nil(t, data.Devices.Add(d2))
+	p := &core.Program{Name: "pr1"}
+	assert.Nil(t, p.AddDevice(d1, time.Second))
+	assert.Nil(t, data.Programs.Add(p))
+
+	// the zone watered by the program is not opened by hand
+	p.Start()
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, core.DeviceInUse, d1.Run(300*time.Millisecond))
+	assert.Nil(t, d2.Run(300*time.Millisecond))
+	time.Sleep(100 * time.Millisecond)
+	assert.True(t, d2.IsOn())
+	time.Sleep(300 * time.Millisecond)
+	assert.True(t, d1.IsOn())
+	assert.True(t, p.IsRunning())
+	p.Stop()
+
+	// with serialized programs no zone is opened while a program runs
+	assert.Nil(t, data.Settings.Set(&core.Settings{Serialize: true, OverlapPolicy: core.OverlapQueue}))
+	p.Start()
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, core.OtherProgramRunning, d2.Run(300*time.Millisecond))
+	assert.False(t, d2.IsOn())
+	p.Stop()
+	assert.Nil(t, d2.Run(100*time.Millisecond))
+	time.Sleep(200 * time.Millisecond)
+	core.NewData()
+}
@@ -5,25 +5,44 @@ import (
 	"io/ioutil"
 	"log"
 	"os"
+	"sync"
+	"time"
 )
 
 type Data struct {
 	Devices   *Devices   `json:"devices"`
 	Programs  *Programs  `json:"programs"`
 	Schedules *Schedules `json:"schedules"`
+	Settings  *Settings  `json:"settings"`
+	Sensors   *Sensors   `json:"sensors"`
+	Usage     *Usage     `json:"usage"`
+	Events    *Events    `json:"events"`
+	Queue     *Queue     `json:"-"`
+	loaded    bool
 }
 
 func NewData() *Data {
-	return &Data{Devices: NewDevices(), Programs: NewPrograms(), Schedules: NewSchedules()}
+	data := &Data{Devices: NewDevices(), Programs: NewPrograms(), Schedules: NewSchedules(), Settings: NewSettings(), Sensors: NewSensors(), Usage: NewUsage(), Events: NewEvents(), Queue: NewQueue()}
+	ctrl.setData(data)
+	return data
 }
 
 var DataFile = "/var/lib/sprinkler.data"
 
+// storeM serializes the writes of the data file
+var storeM sync.Mutex
+
+// mapsM guards the device, program, schedule and sensor maps, they are
+// changed by the api and by the timers of the schedules
+var mapsM sync.RWMutex
+
 func LoadState() *Data {
 	file, err := os.Open(DataFile)
 	if err != nil {
 		if os.IsNotExist(err) {
-			return NewData()
+			data := NewData()
+			data.loaded = true
+			return data
 		}
 		log.Printf("failed to open data file: %v", err)
 		return nil
@@ -36,38 +55,101 @@ func LoadState() *Data {
 		return nil
 	}
 
-	// re-initialize the gpio members
-	for _, dev := range *data.Devices {
-		dev.SetState(dev.Pin, dev.On)
+	// re-initialize the gpio members, the zones and the masters switched on by
+	// an interrupted program are closed, it opens them again if it is resumed
+	for _, dev := range data.Devices.List() {
+		on := dev.On && !dev.IsMaster() && !data.Programs.IsDeviceInUse(dev.Name)
+		dev.SetState(dev.Pin, on)
 	}
 
 	// re-initialize the device pointers
-	for _, pr := range *data.Programs {
+	for _, pr := range data.Programs.List() {
 		for _, elem := range pr.Elements {
 			elem.Device, err = data.Devices.Get(elem.DeviceName)
 			if err != nil {
 				log.Printf("invalid data file, device %s not found", elem.DeviceName)
 				return nil
 			}
+			elem.Parallel = nil
+			for _, name := range elem.ParallelNames {
+				dev, err := data.Devices.Get(name)
+				if err != nil {
+					log.Printf("invalid data file, device %s not found", name)
+					return nil
+				}
+				elem.Parallel = append(elem.Parallel, dev)
+			}
 		}
 	}
 
-	// re-initialize the program pointers
-	for _, sc := range *data.Schedules {
+	// re-initialize the program pointers and the parsed specs
+	for _, sc := range data.Schedules.List() {
 		sc.Program, err = data.Programs.Get(sc.ProgramName)
 		if err != nil {
 			log.Printf("invalid data file, program %s not found", sc.ProgramName)
 			return nil
 		}
+		err = sc.SetSpec(sc.Spec)
+		if err != nil {
+			log.Printf("invalid data file, invalid spec of schedule %s", sc.Name)
+			return nil
+		}
+	}
+
+	// start reading the sensors
+	for _, sensor := range data.Sensors.List() {
+		sensor.Init()
+	}
+
+	// the schedules record their runs in the data file from now on
+	data.loaded = true
+
+	// resume the programs interrupted by the shutdown
+	for _, pr := range data.Programs.List() {
+		if pr.GetProgress() == nil || pr.IsPaused() {
+			continue
+		}
+		if !data.Settings.GetResume() {
+			pr.ClearProgress()
+			continue
+		}
+		log.Printf("resuming the interrupted program %s", pr.Name)
+		if _, err := data.Queue.Submit(pr, "resume after restart"); err != nil {
+			// the program starts from its first step the next time
+			log.Printf("program %s is not resumed: %v", pr.Name, err)
+			pr.ClearProgress()
+		}
+	}
+
+	data.Schedules.CatchUp(time.Now())
+
+	// arm the enabled schedules
+	for _, sc := range data.Schedules.List() {
+		if sc.IsEnabled() {
+			sc.Enable()
+		}
 	}
 	return data
 }
 
+// StoreState saves the data, the file is replaced at once so an interrupted
+// write does not corrupt it
 func (d *Data) StoreState() {
+	storeM.Lock()
+	defer storeM.Unlock()
+
 	js, err := json.Marshal(d)
 	if err != nil {
 		log.Printf("failed to convert data to json: %v", err)
+		return
 	}
 
-	err = ioutil.WriteFile(DataFile, js, 0744)
+	tmp := DataFile + ".tmp"
+	err = ioutil.WriteFile(tmp, js, 0744)
+	if err == nil {
+		err = os.Rename(tmp, DataFile)
+	}
+	if err != nil {
+		log.Printf("failed to write data file: %v", err)
+	}
 }
@@ -1,9 +1,12 @@
 package core_test
 
 import (
+	"fmt"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/peter-vaczi/sprinkler/core"
 	"github.com/stretchr/testify/assert"
@@ -56,3 +59,177 @@ func TestEventloopLoadStore(t *testing.T) {
 	os.Remove("data_test2.json")
 	assert.Equal(t, str1, str2)
 }
+
+func TestEventloopRestore(t *testing.T) {
+	gpioStub := NewGpioStub()
+	core.InitGpio(gpioStub)
+	dir, err := ioutil.TempDir("", "sprinkler")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	state := `{"devices":{"dev1":{"name":"dev1","pin":1}},` +
+		`"programs":{"pr1":{"name":"pr1","devices":[{"device":"dev1","duration":2000000000}],"progress":{"step":0,"remaining":1000000000,"scale":100}},` +
+		`"pr2":{"name":"pr2","devices":[{"device":"dev1","duration":2000000000}],"progress":{"step":0,"remaining":1000000000,"scale":100}}},` +
+		`"schedules":{"sch1":{"name":"sch1","program":"pr2","spec":"0 6 * * *","enabled":true}},` +
+		`"settings":%s}`
+
+	// the interrupted programs are resumed, the enabled schedules are armed
+	core.DataFile = filepath.Join(dir, "sprinkler.data")
+	assert.Nil(t, ioutil.WriteFile(core.DataFile, []byte(fmt.Sprintf(state, `{"resume":true}`)), 0644))
+	data := core.LoadState()
+	if assert.NotNil(t, data) {
+		pr1, _ := data.Programs.Get("pr1")
+		sch1, _ := data.Schedules.Get("sch1")
+		assert.True(t, sch1.IsEnabled())
+		assert.True(t, pr1.IsRunning())
+		time.Sleep(100 * time.Millisecond)
+		dev1, _ := data.Devices.Get("dev1")
+		assert.True(t, dev1.IsOn())
+		time.Sleep(1200 * time.Millisecond)
+		assert.False(t, dev1.IsOn())
+
+		// the shutdown keeps the schedules enabled
+		data.Schedules.StopAll()
+		data.Programs.SuspendAll()
+		data.StoreState()
+		str, _ := ioutil.ReadFile(core.DataFile)
+		assert.Contains(t, string(str), `"enabled":true`)
+	}
+
+	// the serialized programs are resumed one after the other
+	assert.Nil(t, ioutil.WriteFile(core.DataFile, []byte(fmt.Sprintf(state, `{"resume":true,"serialize":true,"overlap-policy":"queue"}`)), 0644))
+	data = core.LoadState()
+	if assert.NotNil(t, data) {
+		pr1, _ := data.Programs.Get("pr1")
+		pr2, _ := data.Programs.Get("pr2")
+		assert.True(t, pr1.IsRunning() != pr2.IsRunning())
+		assert.Equal(t, 1, len(data.Queue.List()))
+		data.Schedules.StopAll()
+		data.Queue.Clear()
+		data.Programs.StopAll()
+	}
+
+	// the progress is dropped without resume
+	assert.Nil(t, ioutil.WriteFile(core.DataFile, []byte(fmt.Sprintf(state, `{}`)), 0644))
+	data = core.LoadState()
+	if assert.NotNil(t, data) {
+		pr1, _ := data.Programs.Get("pr1")
+		assert.False(t, pr1.IsRunning())
+		assert.Nil(t, pr1.GetProgress())
+		data.Schedules.StopAll()
+	}
+	core.NewData()
+}
+
+func TestEventloopStoreRainDelay(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sprinkler")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	// the rain delay is saved without waiting for the shutdown
+	core.DataFile = filepath.Join(dir, "sprinkler.data")
+	data := core.LoadState()
+	if assert.NotNil(t, data) {
+		data.Settings.SetRainDelay(time.Now().Add(time.Hour))
+		str, _ := ioutil.ReadFile(core.DataFile)
+		assert.Contains(t, string(str), `"rain-delay"`)
+
+		data.Settings.ClearRainDelay()
+		str, _ = ioutil.ReadFile(core.DataFile)
+		assert.NotContains(t, string(str), `"rain-delay"`)
+	}
+	core.NewData()
+}
+
+func TestEventloopStoreConcurrently(t *testing.T) {
+	gpioStub := NewGpioStub()
+	core.InitGpio(gpioStub)
+	dir, err := ioutil.TempDir("", "sprinkler")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	// the data is saved by the timers and the running programs while the
+	// api changes it
+	core.DataFile = filepath.Join(dir, "sprinkler.data")
+	data := core.LoadState()
+	if assert.NotNil(t, data) {
+		zone := &core.Device{Name: "zone", Pin: 100}
+		assert.Nil(t, data.Devices.Add(zone))
+		delay := time.Millisecond
+		pr := &core.Program{Name: "pr", Delay: &delay}
+		for i := 0; i < 10; i++ {
+			assert.Nil(t, pr.AddDevice(zone, 10*time.Millisecond))
+		}
+		assert.Nil(t, data.Programs.Add(pr))
+		pr.Start()
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			for i := 0; i < 100; i++ {
+				data.StoreState()
+			}
+		}()
+		for i := 0; i < 100; i++ {
+			name := fmt.Sprintf("dev%d", i)
+			assert.Nil(t, data.Devices.Add(&core.Device{Name: name, Pin: i}))
+			assert.Nil(t, data.Programs.Add(&core.Program{Name: name}))
+			assert.Nil(t, data.Devices.Del(name))
+			assert.Nil(t, data.Programs.Del(name))
+		}
+		<-done
+		pr.Stop()
+
+		files, _ := ioutil.ReadDir(dir)
+		assert.Equal(t, 1, len(files))
+		assert.NotNil(t, core.LoadState())
+	}
+	core.NewData()
+}
+
+func TestEventloopStoreProgress(t *testing.T) {
+	gpioStub := NewGpioStub()
+	core.InitGpio(gpioStub)
+	dir, err := ioutil.TempDir("", "sprinkler")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	core.DataFile = filepath.Join(dir, "sprinkler.data")
+	data := core.LoadState()
+	if assert.NotNil(t, data) {
+		zone := &core.Device{Name: "zone", Pin: 100}
+		assert.Nil(t, data.Devices.Add(zone))
+		pr := &core.Program{Name: "pr"}
+		assert.Nil(t, pr.AddDevice(zone, 200*time.Millisecond))
+		assert.Nil(t, data.Programs.Add(pr))
+
+		// the checkpoint of a finished run is not left in the data file
+		pr.Start()
+		time.Sleep(1500 * time.Millisecond)
+		assert.False(t, pr.IsRunning())
+		str, _ := ioutil.ReadFile(core.DataFile)
+		assert.NotContains(t, string(str), `"progress"`)
+
+		// nor the one of a stopped run
+		pr.Start()
+		time.Sleep(100 * time.Millisecond)
+		pr.Stop()
+		str, _ = ioutil.ReadFile(core.DataFile)
+		assert.NotContains(t, string(str), `"progress"`)
+		assert.NotContains(t, string(str), `"on":true`)
+	}
+
+	// the zone left open by the checkpoint of an interrupted run is closed
+	state := `{"devices":{"zone":{"name":"zone","on":true,"pin":100},"spare":{"name":"spare","on":true,"pin":101}},` +
+		`"programs":{"pr":{"name":"pr","devices":[{"device":"zone","duration":2000000000}],"progress":{"step":0,"remaining":1000000000,"scale":100}}}}`
+	assert.Nil(t, ioutil.WriteFile(core.DataFile, []byte(state), 0644))
+	data = core.LoadState()
+	if assert.NotNil(t, data) {
+		zone, _ := data.Devices.Get("zone")
+		spare, _ := data.Devices.Get("spare")
+		assert.False(t, zone.IsOn())
+		assert.True(t, spare.IsOn())
+		spare.TurnOff()
+	}
+	core.NewData()
+}
@@ -0,0 +1,91 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"sync"
+	"time"
+)
+
+var (
+	InvalidTime = errors.New("Invalid time")
+)
+
+// event types
+const (
+	EventHighFlow      = "high-flow"
+	EventLowFlow       = "low-flow"
+	EventLeak          = "leak"
+	EventVolumeTimeout = "volume-timeout"
+	EventMaxOn         = "max-on"
+)
+
+// maxEvents is the number of the most recent events kept
+const maxEvents = 1000
+
+// Event is a fault detected by the controller
+type Event struct {
+	Time    time.Time `json:"time"`
+	Type    string    `json:"type"`
+	Device  string    `json:"device,omitempty"`
+	Program string    `json:"program,omitempty"`
+	Message string    `json:"message"`
+}
+
+// Events is the log of the detected faults, oldest first
+type Events struct {
+	List []*Event `json:"list,omitempty"`
+	m    sync.Mutex
+}
+
+func NewEvents() *Events {
+	return &Events{}
+}
+
+// Add records a new event, the oldest events are dropped above maxEvents
+func (e *Events) Add(event *Event) {
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	log.Printf("ALERT: %s", event.Message)
+
+	e.m.Lock()
+	defer e.m.Unlock()
+
+	e.List = append(e.List, event)
+	if len(e.List) > maxEvents {
+		e.List = e.List[len(e.List)-maxEvents:]
+	}
+}
+
+// Get returns the events recorded since the time t
+func (e *Events) Get(since time.Time) []*Event {
+	e.m.Lock()
+	defer e.m.Unlock()
+
+	events := []*Event{}
+	for _, event := range e.List {
+		if !event.Time.Before(since) {
+			events = append(events, event)
+		}
+	}
+	return events
+}
+
+func (e *Events) Clear() {
+	e.m.Lock()
+	defer e.m.Unlock()
+
+	e.List = nil
+}
+
+// MarshalJSON encodes the events while holding their lock
+func (e *Events) MarshalJSON() ([]byte, error) {
+	type events Events
+
+	e.m.Lock()
+	defer e.m.Unlock()
+
+	return json.Marshal((*events)(e))
+}
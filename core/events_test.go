@@ -0,0 +1,88 @@
+package core_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/peter-vaczi/sprinkler/core"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFlowFaults(t *testing.T) {
+	gpioStub := NewGpioStub()
+	core.InitGpio(gpioStub)
+	data := core.NewData()
+	assert.Equal(t, core.InvalidSettings, data.Settings.Set(&core.Settings{FlowAction: "ignore"}))
+	assert.Nil(t, data.Settings.Set(&core.Settings{FlowWindow: 200 * time.Millisecond, LeakFlow: 10}))
+
+	assert.Equal(t, core.InvalidFlow, data.Devices.Add(&core.Device{Name: "dev1", Pin: 1, Flow: -1}))
+	d1 := &core.Device{Name: "dev1", Pin: 1, Flow: 300}
+	d2 := &core.Device{Name: "dev2", Pin: 2, Flow: 100}
+	d3 := &core.Device{Name: "dev3", Pin: 3, Flow: 300}
+	d4 := &core.Device{Name: "dev4", Pin: 4}
+	for _, d := range []*core.Device{d1, d2, d3, d4} {
+		assert.Nil(t, data.Devices.Add(d))
+	}
+	assert.Nil(t, data.Sensors.Add(&core.Sensor{Name: "flow", Type: core.SensorFlow, Pin: 6, KFactor: 10}))
+
+	// the flow meter pulses while any of dev1, dev2 and dev4 is on or while
+	// leaking
+	var m sync.Mutex
+	leaking := false
+	stop := gpioStub.pins[6].pulse(func() bool {
+		m.Lock()
+		defer m.Unlock()
+		return leaking || d1.IsOn() || d2.IsOn() || d4.IsOn()
+	})
+
+	// dev2 flows 3 times more than expected, it is skipped
+	p1 := &core.Program{Name: "pr1"}
+	assert.Nil(t, p1.AddDevice(d1, time.Second))
+	assert.Nil(t, p1.AddDevice(d2, time.Second))
+	assert.Nil(t, p1.AddDevice(d4, time.Second))
+	assert.Nil(t, data.Programs.Add(p1))
+	p1.Start()
+	time.Sleep(2200 * time.Millisecond)
+	assert.True(t, d2.IsOn())
+	time.Sleep(400 * time.Millisecond)
+	assert.False(t, d2.IsOn())
+	time.Sleep(2200 * time.Millisecond)
+	assert.False(t, d4.IsOn())
+
+	events := data.Events.Get(time.Time{})
+	assert.Len(t, events, 1)
+	assert.Equal(t, core.EventHighFlow, events[0].Type)
+	assert.Equal(t, "dev2", events[0].Device)
+	assert.Equal(t, "pr1", events[0].Program)
+	assert.Equal(t, 300.0, d1.GetFlow())
+	assert.InDelta(t, 300.0, d4.GetFlow(), 60)
+
+	// dev3 does not flow, the program is stopped
+	assert.Nil(t, data.Settings.Set(&core.Settings{FlowWindow: 200 * time.Millisecond, FlowAction: core.FlowStop, LeakFlow: 10}))
+	p2 := &core.Program{Name: "pr2"}
+	assert.Nil(t, p2.AddDevice(d3, time.Second))
+	assert.Nil(t, p2.AddDevice(d1, time.Second))
+	assert.Nil(t, data.Programs.Add(p2))
+	p2.Start()
+	time.Sleep(2500 * time.Millisecond)
+	assert.False(t, d1.IsOn())
+	events = data.Events.Get(time.Time{})
+	assert.Len(t, events, 2)
+	assert.Equal(t, core.EventLowFlow, events[1].Type)
+	assert.Equal(t, "dev3", events[1].Device)
+
+	// flow while every zone is closed
+	m.Lock()
+	leaking = true
+	m.Unlock()
+	time.Sleep(300 * time.Millisecond)
+	events = data.Events.Get(time.Time{})
+	assert.Len(t, events, 3)
+	assert.Equal(t, core.EventLeak, events[2].Type)
+
+	stop()
+	data.Sensors.StopAll()
+	time.Sleep(100 * time.Millisecond)
+	core.NewData()
+}
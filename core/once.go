@@ -0,0 +1,81 @@
+package core
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron"
+)
+
+var (
+	TimeInPast = errors.New("Time is in the past")
+)
+
+// Once is the kind of the one-shot schedules
+const Once = "@once"
+
+// OnceDelete makes a one-shot schedule delete itself after its run
+const OnceDelete = "delete"
+
+// onceLayout is the format of the time of the one-shot schedules
+const onceLayout = dayLayout + " 15:04"
+
+// onceSchedule starts the program once at the given time, the schedule is
+// disabled after the run, with remove it is deleted
+type onceSchedule struct {
+	at     time.Time
+	remove bool
+}
+
+// parseOnceSpec parses the spec of a one-shot schedule:
+// @once <YYYY-MM-DD> <HH:MM> [delete] in the local time of the daemon, e.g.
+// "@once 2026-10-19 06:30 delete", or @once <RFC3339 time> [delete], e.g.
+// "@once 2026-10-19T04:30:00Z delete"
+func parseOnceSpec(spec string) (*onceSchedule, error) {
+	fields := strings.Fields(spec)
+	if len(fields) < 2 || fields[0] != Once {
+		return nil, InvalidSpec
+	}
+	at, err := time.Parse(time.RFC3339, fields[1])
+	if err == nil {
+		at = at.Local()
+		fields = fields[2:]
+	} else {
+		if len(fields) < 3 {
+			return nil, InvalidSpec
+		}
+		at, err = time.ParseInLocation(onceLayout, fields[1]+" "+fields[2], time.Local)
+		if err != nil {
+			return nil, InvalidSpec
+		}
+		fields = fields[3:]
+	}
+
+	sc := &onceSchedule{at: at}
+	if len(fields) > 1 {
+		return nil, InvalidSpec
+	}
+	if len(fields) == 1 {
+		if fields[0] != OnceDelete {
+			return nil, InvalidSpec
+		}
+		sc.remove = true
+	}
+	return sc, nil
+}
+
+// Next returns the time of the run if it is after t, the zero time otherwise
+func (s *onceSchedule) Next(t time.Time) time.Time {
+	if s.at.After(t) {
+		return s.at
+	}
+	return time.Time{}
+}
+
+// isPast returns true if the schedule is a one-shot schedule without a run
+// after the time t, it would never start its program
+func isPast(sc cron.Schedule, t time.Time) bool {
+	once, ok := sc.(*onceSchedule)
+	return ok && once.Next(t).IsZero()
+}
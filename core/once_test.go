@@ -0,0 +1,73 @@
+package core_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/peter-vaczi/sprinkler/core"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOnceSchedules(t *testing.T) {
+	gpioStub := NewGpioStub()
+	core.InitGpio(gpioStub)
+	data := core.NewData()
+	d1 := &core.Device{Name: "dev1", Pin: 1}
+	assert.Nil(t, data.Devices.Add(d1))
+	p := &core.Program{Name: "pr1"}
+	assert.Nil(t, p.AddDevice(d1, 1*time.Second))
+	assert.Nil(t, data.Programs.Add(p))
+
+	for _, spec := range []string{"@once", "@once 2026-10-19", "@once 2026-10-19 6am", "@once 2026-10-19 06:30 keep",
+		"@once 2100-01-01T04:30:00Z keep", "@once 2100-01-01T04:30:00Z delete now"} {
+		assert.Equal(t, core.InvalidSpec, data.Schedules.Add(&core.Schedule{Name: "sc1", Spec: spec}))
+	}
+
+	// the run is in the future
+	at := time.Now().Add(48 * time.Hour).Truncate(time.Minute)
+	s1 := &core.Schedule{Name: "sc1", Spec: "@once " + at.Format("2006-01-02 15:04"), Program: p}
+	assert.Nil(t, data.Schedules.Add(s1))
+	assert.True(t, s1.GetNext().Equal(at))
+
+	// the time sent with its offset is the same instant in the local time
+	assert.Nil(t, s1.SetSpec("@once "+at.UTC().Format(time.RFC3339)+" delete"))
+	assert.True(t, s1.GetNext().Equal(at))
+	assert.Nil(t, s1.SetSpec("@once "+at.In(time.FixedZone("", 5*3600)).Format(time.RFC3339)))
+	assert.True(t, s1.GetNext().Equal(at))
+
+	// a run in the past is rejected, it would never start the program
+	assert.Equal(t, core.TimeInPast, data.Schedules.Add(&core.Schedule{Name: "sc2", Spec: "@once 2026-10-18 06:00", Program: p}))
+	assert.Equal(t, core.TimeInPast, data.Schedules.Set("sc1", &core.Schedule{Spec: "@once 2026-10-18 06:00", Program: p}))
+
+	// the run is over
+	assert.Nil(t, s1.SetSpec("@once 2026-10-18 06:00"))
+	assert.True(t, s1.GetNext().IsZero())
+
+	// the missed run is started by the catch-up and the schedule is disabled
+	now := time.Date(2026, 10, 18, 6, 30, 0, 0, time.Local)
+	enabled := now.Add(-time.Hour)
+	assert.Nil(t, data.Settings.Set(&core.Settings{CatchUp: time.Hour}))
+	s1.Enable()
+	// the schedule was enabled before its run
+	s1.LastRun = &enabled
+	data.Schedules.CatchUp(now)
+	time.Sleep(100 * time.Millisecond)
+	assert.True(t, p.IsRunning())
+	assert.False(t, s1.IsEnabled())
+	_, err := data.Schedules.Get("sc1")
+	assert.Nil(t, err)
+	data.Programs.StopAll()
+
+	// the missed run is skipped and the schedule is deleted
+	s2 := &core.Schedule{Name: "sc2", Spec: "@once " + at.Format("2006-01-02 15:04") + " delete", Program: p}
+	assert.Nil(t, data.Schedules.Add(s2))
+	assert.Nil(t, s2.SetSpec("@once 2026-10-18 06:00 delete"))
+	s2.Enable()
+	s2.LastRun = &enabled
+	data.Schedules.CatchUp(now.Add(2 * time.Hour))
+	assert.False(t, p.IsRunning())
+	assert.NotNil(t, s2.GetSkipped())
+	_, err = data.Schedules.Get("sc2")
+	assert.Equal(t, core.NotFound, err)
+	core.NewData()
+}
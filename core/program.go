@@ -2,31 +2,93 @@ package core
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"log"
 	"sync"
 	"time"
 )
 
+// ProgramElement opens its devices for Duration, or until Volume liters of
+// water is measured by the flow sensors, in which case Duration is the
+// ceiling of the run time
 type ProgramElement struct {
-	DeviceName string        `json:"device"`
-	Device     *Device       `json:"-"`
-	Duration   time.Duration `json:"duration"`
+	DeviceName    string        `json:"device"`
+	Device        *Device       `json:"-"`
+	ParallelNames []string      `json:"parallel,omitempty"`
+	Parallel      []*Device     `json:"-"`
+	Duration      time.Duration `json:"duration"`
+	Cycle         time.Duration `json:"cycle,omitempty"`
+	Soak          time.Duration `json:"soak,omitempty"`
+	Volume        float64       `json:"volume,omitempty"`
 }
 
+// Zones returns every device opened by this element
+func (e *ProgramElement) Zones() []*Device {
+	return append([]*Device{e.Device}, e.Parallel...)
+}
+
+// Program opens the zones of its elements one after the other
 type Program struct {
-	Name     string            `json:"name"`
-	Elements []*ProgramElement `json:"devices"`
-	ctx      context.Context
-	cancel   context.CancelFunc
-	running  bool
-	m        sync.Mutex
+	Name       string            `json:"name"`
+	Elements   []*ProgramElement `json:"devices"`
+	Adjustment Adjustment        `json:"adjustment,omitempty"`
+	// Progress is the checkpoint of a paused or interrupted run
+	Progress *Progress `json:"progress,omitempty"`
+	Paused   bool      `json:"paused,omitempty"`
+	// Delay is the pause between two steps, nil means the default
+	Delay *time.Duration `json:"delay,omitempty"`
+	// Overlap opens the next zone that long before the previous one closes
+	Overlap    time.Duration `json:"overlap,omitempty"`
+	ctx        context.Context
+	cancel     context.CancelFunc
+	done       chan struct{}
+	jumps      chan int
+	running    bool
+	keep       bool
+	started    time.Time
+	source     string
+	steps      []*Step
+	step       int
+	stepStart  time.Time
+	stepLength time.Duration
+	m          sync.Mutex
 }
 
+// Progress is the step a program is running with the time and, for volume
+// based steps, the liters of water it has left, the steps are of the timeline
+// scaled by Scale percent
+type Progress struct {
+	Step      int           `json:"step"`
+	Remaining time.Duration `json:"remaining"`
+	Volume    float64       `json:"volume,omitempty"`
+	Scale     int           `json:"scale"`
+}
+
+// jump interrupts the running step of a program to continue at the step
+// that many steps away
+type jump int
+
+func (j jump) Error() string {
+	return fmt.Sprintf("jump %d steps", int(j))
+}
+
+// checkpointInterval is how often the progress of the running programs is
+// saved
+var checkpointInterval = 1 * time.Minute
+
 type Programs map[string]*Program
 
 var (
-	OutOfRange = errors.New("Element index out of range")
+	OutOfRange      = errors.New("Element index out of range")
+	DeviceIsMaster  = errors.New("Master devices can not be added to programs")
+	InvalidDuration = errors.New("Invalid duration")
+	HighFlow        = errors.New("Flow is too high")
+	LowFlow         = errors.New("Flow is too low")
+	InvalidVolume   = errors.New("Invalid volume")
+	NotRunning      = errors.New("Program is not running")
+	NotPaused       = errors.New("Program is not paused")
 )
 
 func NewPrograms() *Programs {
@@ -35,9 +97,15 @@ func NewPrograms() *Programs {
 }
 
 func (p *Programs) Add(prog *Program) error {
+	mapsM.Lock()
+	defer mapsM.Unlock()
+
 	if _, exists := (*p)[prog.Name]; exists {
 		return AlreadyExists
 	}
+	if (prog.Delay != nil && *prog.Delay < 0) || prog.Overlap < 0 {
+		return InvalidDuration
+	}
 
 	(*p)[prog.Name] = prog
 
@@ -45,6 +113,9 @@ func (p *Programs) Add(prog *Program) error {
 }
 
 func (p *Programs) Get(name string) (*Program, error) {
+	mapsM.RLock()
+	defer mapsM.RUnlock()
+
 	if prg, exists := (*p)[name]; exists {
 		return prg, nil
 	}
@@ -53,6 +124,9 @@ func (p *Programs) Get(name string) (*Program, error) {
 }
 
 func (p *Programs) Del(name string) error {
+	mapsM.Lock()
+	defer mapsM.Unlock()
+
 	if _, exists := (*p)[name]; exists {
 		delete(*p, name)
 		return nil
@@ -61,29 +135,158 @@ func (p *Programs) Del(name string) error {
 	return NotFound
 }
 
-func (p *Programs) IsDeviceInUse(name string) bool {
+// List returns the programs in no particular order
+func (p *Programs) List() []*Program {
+	mapsM.RLock()
+	defer mapsM.RUnlock()
+
+	progs := make([]*Program, 0, len(*p))
 	for _, pr := range *p {
-		for _, e := range pr.Elements {
-			if e.DeviceName == name {
-				return true
-			}
+		progs = append(progs, pr)
+	}
+	return progs
+}
+
+// MarshalJSON encodes the programs while holding the lock of the maps
+func (p *Programs) MarshalJSON() ([]byte, error) {
+	type programs Programs
+
+	mapsM.RLock()
+	defer mapsM.RUnlock()
+
+	return json.Marshal((*programs)(p))
+}
+
+func (p *Programs) IsDeviceInUse(name string) bool {
+	for _, pr := range p.List() {
+		if pr.usesDevice(name) {
+			return true
 		}
 	}
 	return false
 }
 
 func (p *Programs) StopAll() {
-	for _, pr := range *p {
+	for _, pr := range p.List() {
 		pr.Stop()
 	}
 }
 
-func (p *Program) AddDevice(device *Device, duration time.Duration) error {
+// SuspendAll stops the running programs keeping their progress to resume them
+// later
+func (p *Programs) SuspendAll() {
+	for _, pr := range p.List() {
+		pr.Suspend()
+	}
+}
+
+// usesDevice returns true if any element of the program opens the device of
+// the given name
+func (p *Program) usesDevice(name string) bool {
+	p.m.Lock()
+	defer p.m.Unlock()
+
+	for _, e := range p.Elements {
+		if e.DeviceName == name {
+			return true
+		}
+		for _, n := range e.ParallelNames {
+			if n == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// AddDevice appends a new element to the program, the parallel devices are
+// opened together with the device for the same duration
+func (p *Program) AddDevice(device *Device, duration time.Duration, parallel ...*Device) error {
+	return p.AddElement(&ProgramElement{Device: device, Parallel: parallel, Duration: duration})
+}
+
+// AddElement appends a new element to the program, the device names of the
+// element are set from its device pointers
+func (p *Program) AddElement(elem *ProgramElement) error {
+	if err := elem.validate(); err != nil {
+		return err
+	}
+
+	p.m.Lock()
+	defer p.m.Unlock()
+
+	p.setElements(append(p.Elements[:len(p.Elements):len(p.Elements)], elem))
+
+	return nil
+}
+
+// InsertElement inserts a new element before the element at the index idx,
+// idx equal to the number of elements appends it
+func (p *Program) InsertElement(idx int, elem *ProgramElement) error {
+	if err := elem.validate(); err != nil {
+		return err
+	}
+
+	p.m.Lock()
+	defer p.m.Unlock()
+
+	if idx < 0 || idx > len(p.Elements) {
+		return OutOfRange
+	}
+	elems := append([]*ProgramElement{}, p.Elements[:idx]...)
+	elems = append(elems, elem)
+	p.setElements(append(elems, p.Elements[idx:]...))
+	return nil
+}
+
+// SetElement replaces the element at the index idx
+func (p *Program) SetElement(idx int, elem *ProgramElement) error {
+	if err := elem.validate(); err != nil {
+		return err
+	}
+
 	p.m.Lock()
 	defer p.m.Unlock()
 
-	p.Elements = append(p.Elements, &ProgramElement{DeviceName: device.Name, Device: device, Duration: duration})
+	if idx < 0 || idx >= len(p.Elements) {
+		return OutOfRange
+	}
+	elems := append([]*ProgramElement{}, p.Elements...)
+	elems[idx] = elem
+	p.setElements(elems)
+	return nil
+}
+
+// MoveElement moves the element at the index from to the index to, the
+// elements between them are shifted
+func (p *Program) MoveElement(from, to int) error {
+	p.m.Lock()
+	defer p.m.Unlock()
 
+	if from < 0 || from >= len(p.Elements) || to < 0 || to >= len(p.Elements) {
+		return OutOfRange
+	}
+	elem := p.Elements[from]
+	elems := append([]*ProgramElement{}, p.Elements[:from]...)
+	elems = append(elems, p.Elements[from+1:]...)
+	elems = append(elems[:to], append([]*ProgramElement{elem}, elems[to:]...)...)
+	p.setElements(elems)
+	return nil
+}
+
+// SetElements replaces every element of the program, nothing is changed if
+// any of the new elements is invalid
+func (p *Program) SetElements(elems []*ProgramElement) error {
+	for _, elem := range elems {
+		if err := elem.validate(); err != nil {
+			return err
+		}
+	}
+
+	p.m.Lock()
+	defer p.m.Unlock()
+
+	p.setElements(append([]*ProgramElement{}, elems...))
 	return nil
 }
 
@@ -91,74 +294,650 @@ func (p *Program) DelDevice(idx int) error {
 	p.m.Lock()
 	defer p.m.Unlock()
 
-	if idx >= len(p.Elements) {
+	if idx < 0 || idx >= len(p.Elements) {
 		return OutOfRange
 	}
-	p.Elements = append(p.Elements[:idx], p.Elements[idx+1:]...)
+	elems := append([]*ProgramElement{}, p.Elements[:idx]...)
+	p.setElements(append(elems, p.Elements[idx+1:]...))
+	return nil
+}
+
+// setElements sets the elements of the program, a running program keeps the
+// elements it was started with until it finishes, a paused program is not
+// paused anymore, its progress is dropped as its steps are not valid anymore,
+// it must be called with the lock held
+func (p *Program) setElements(elems []*ProgramElement) {
+	p.Elements = elems
+	if !p.running {
+		p.Progress = nil
+		p.Paused = false
+	}
+}
+
+// validate checks the element and sets its device names from its device
+// pointers
+func (e *ProgramElement) validate() error {
+	if e.Device == nil {
+		return NotFound
+	}
+	e.DeviceName = e.Device.Name
+	e.ParallelNames = nil
+	for _, dev := range e.Parallel {
+		e.ParallelNames = append(e.ParallelNames, dev.Name)
+	}
+	for _, dev := range e.Zones() {
+		if dev.IsMaster() {
+			return DeviceIsMaster
+		}
+	}
+	if e.Duration < 0 || e.Cycle < 0 || e.Soak < 0 {
+		return InvalidDuration
+	}
+	if e.Volume < 0 || (e.Volume > 0 && e.Cycle > 0) {
+		return InvalidVolume
+	}
+	return nil
+}
+
+// SetDelay sets the pause between two steps of the program and the time the
+// zones of two steps may be open together, a nil delay means the default one
+func (p *Program) SetDelay(delay *time.Duration, overlap time.Duration) error {
+	if (delay != nil && *delay < 0) || overlap < 0 {
+		return InvalidDuration
+	}
+
+	p.m.Lock()
+	defer p.m.Unlock()
+
+	p.Delay = nil
+	if delay != nil {
+		d := *delay
+		p.Delay = &d
+	}
+	p.Overlap = overlap
 	return nil
 }
 
+// delays returns the pause between two steps and the overlap of the steps
+func (p *Program) delays() (time.Duration, time.Duration) {
+	p.m.Lock()
+	defer p.m.Unlock()
+
+	if p.Delay == nil {
+		return zoneDelay, p.Overlap
+	}
+	return *p.Delay, p.Overlap
+}
+
+// SetAdjustment sets the seasonal adjustment table of the program, it
+// overrides the controller wide table for the months it contains
+func (p *Program) SetAdjustment(adj Adjustment) error {
+	if err := adj.validate(); err != nil {
+		return err
+	}
+
+	p.m.Lock()
+	defer p.m.Unlock()
+
+	p.Adjustment = adj.copy()
+	return nil
+}
+
+func (p *Program) GetAdjustment() Adjustment {
+	p.m.Lock()
+	defer p.m.Unlock()
+
+	return p.Adjustment.copy()
+}
+
+// Scale returns the watering scale in percent applied to the durations of
+// the program at the time t
+func (p *Program) Scale(t time.Time) int {
+	if pct, found := p.GetAdjustment().Percent(t); found {
+		return pct
+	}
+	return ctrl.scale(t)
+}
+
 func (p *Program) Start() {
+	p.StartBy("manual")
+}
+
+// StartBy starts the program if it is not running, source tells who started
+// it, e.g. a schedule
+func (p *Program) StartBy(source string) {
 	p.m.Lock()
 	defer p.m.Unlock()
 
 	if !p.running {
+		p.running = true
+		p.started = time.Now()
+		p.source = source
+		p.steps = nil
+		p.stepStart = time.Time{}
+		p.Paused = false
 		p.ctx, p.cancel = context.WithCancel(context.Background())
+		p.done = make(chan struct{})
+		p.jumps = make(chan int, 1)
 		go p.run()
 	}
 }
 
+func (p *Program) IsRunning() bool {
+	p.m.Lock()
+	defer p.m.Unlock()
+
+	return p.running
+}
+
+// Stop cancels the run and forgets its progress, the data file is saved so a
+// restart does not resume the stopped run
 func (p *Program) Stop() {
+	p.stop(false, false)
+	ctrl.storeState()
+}
+
+// Suspend stops the program keeping its progress, the program resumes from
+// there when it is started again
+func (p *Program) Suspend() {
+	p.stop(true, false)
+}
+
+// Pause closes the open valves of the running program keeping the remaining
+// time of the current element, Resume continues from there
+func (p *Program) Pause() error {
+	if !p.IsRunning() {
+		return NotRunning
+	}
+	p.stop(true, true)
+
+	log.Printf("program %s is paused", p.Name)
+	ctrl.storeState()
+	return nil
+}
+
+// Resume continues the paused program from the element and the remaining
+// time it was paused at, it is started through the queue of the controller
+func (p *Program) Resume(source string) (string, error) {
+	if !p.IsPaused() {
+		return "", NotPaused
+	}
+	return ctrl.submit(p, source)
+}
+
+func (p *Program) IsPaused() bool {
+	p.m.Lock()
+	defer p.m.Unlock()
+
+	return p.Paused
+}
+
+// stop cancels the run and switches off the zones of the program, with keep
+// its progress is kept, with pause it is marked paused
+func (p *Program) stop(keep, pause bool) {
 	p.m.Lock()
 	running := p.running
 	cancel := p.cancel
+	done := p.done
+	if !running && !keep {
+		// a paused program forgets its progress
+		p.Progress = nil
+		p.Paused = false
+	}
+	// the canceled run checkpoints its step only if it is kept
+	p.keep = keep
 	p.m.Unlock()
 
 	if running {
 		cancel()
+		<-done
 		p.m.Lock()
 		for _, elem := range p.Elements {
-			elem.Device.TurnOff()
+			for _, dev := range elem.Zones() {
+				dev.TurnOff()
+			}
 		}
+		if !keep {
+			p.Progress = nil
+		}
+		// a run paused after its last step has nothing left to resume
+		p.Paused = pause && p.Progress != nil
 		p.m.Unlock()
 	}
 }
 
+// Next closes the zones of the running step and continues the program with
+// the next step, the program finishes if it was the last one
+func (p *Program) Next() error {
+	return p.jumpBy(1)
+}
+
+// Prev closes the zones of the running step and continues the program with
+// the previous step
+func (p *Program) Prev() error {
+	return p.jumpBy(-1)
+}
+
+// jumpBy asks the running program to continue delta steps away from the
+// running step, or from the next one if no zone is open right now
+func (p *Program) jumpBy(delta int) error {
+	p.m.Lock()
+	defer p.m.Unlock()
+
+	if !p.running {
+		return NotRunning
+	}
+	select {
+	case p.jumps <- delta:
+	default:
+		// a jump is pending already
+	}
+	return nil
+}
+
+// GetProgress returns the checkpoint of the interrupted or running program
+func (p *Program) GetProgress() *Progress {
+	p.m.Lock()
+	defer p.m.Unlock()
+
+	return p.Progress
+}
+
+// ClearProgress drops the checkpoint of the interrupted program, it starts
+// from its first step the next time
+func (p *Program) ClearProgress() {
+	p.m.Lock()
+	defer p.m.Unlock()
+
+	p.Progress = nil
+}
+
+// MarshalJSON encodes the program while holding its lock, its progress is
+// recorded by the run
+func (p *Program) MarshalJSON() ([]byte, error) {
+	type program Program
+
+	p.m.Lock()
+	defer p.m.Unlock()
+
+	return json.Marshal((*program)(p))
+}
+
+// keeping returns true if the progress of the canceled run is kept to resume
+// it later
+func (p *Program) keeping() bool {
+	p.m.Lock()
+	defer p.m.Unlock()
+
+	return p.keep
+}
+
+// checkpoint records the progress of the running program and saves it
+func (p *Program) checkpoint(progress *Progress) {
+	p.m.Lock()
+	p.Progress = progress
+	p.step = progress.Step
+	p.m.Unlock()
+
+	ctrl.storeState()
+}
+
 func (p *Program) run() {
 	p.m.Lock()
-	p.running = true
 	elements := p.Elements
 	p.m.Unlock()
 
 	defer func() {
 		p.m.Lock()
 		p.running = false
+		// the progress of a canceled run is cleared by Stop, kept by Suspend
+		finished := p.ctx.Err() == nil
+		if finished {
+			p.Progress = nil
+		}
+		close(p.done)
 		p.m.Unlock()
+
+		if finished {
+			ctrl.storeState()
+		}
+		ctrl.programDone()
 	}()
 
 	log.Printf("program %s is started", p.Name)
 	for _, elem := range elements {
-		if elem.Device.IsOn() {
-			elem.Device.TurnOff()
+		for _, dev := range elem.Zones() {
+			if dev.IsOn() {
+				dev.TurnOff()
+			}
 		}
 	}
 
-	for _, elem := range elements {
-		elem.Device.TurnOn()
+	scale := p.Scale(time.Now())
+	progress := p.GetProgress()
+	if progress != nil {
+		scale = progress.Scale
+	}
+	if scale != 100 {
+		log.Printf("program %s: durations are scaled to %d%%", p.Name, scale)
+	}
 
-		t := time.NewTimer(elem.Duration)
+	delay, overlap := p.delays()
+	steps := timeline(elements, scale, delay, overlap)
+	first := 0
+	if progress != nil && progress.Step < len(steps) {
+		// the interrupted step runs for the time or volume it has left
+		first = progress.Step
+		resumed := *steps[first]
+		resumed.Duration = progress.Remaining
+		finished := resumed.Duration <= 0
+		if resumed.Volume > 0 {
+			resumed.Volume = progress.Volume
+			finished = resumed.Volume <= 0
+		}
+		steps[first] = &resumed
+		log.Printf("program %s is resumed at step %d", p.Name, first)
+		if finished {
+			first++
+		}
+	}
+	p.m.Lock()
+	p.steps = steps
+	p.step = first
+	p.m.Unlock()
+
+	// the zones of the previous step are kept open until the next step opens
+	var prev []*Device
+	var prevElem *ProgramElement
+	closePrev := func() {
+		if prev != nil {
+			ctrl.closeZones(prev...)
+			prev = nil
+		}
+	}
+	defer closePrev()
+	// the previous zones are closed without overlapping the next step
+	releasePrev := func() bool {
+		closePrev()
+		return sleep(p.ctx, prevElem.gap(delay))
+	}
+
+	// the earliest start of the next cycle of the elements
+	ready := make([]time.Time, len(elements))
+	// the elements skipped because of their flow
+	skipped := make([]bool, len(elements))
+
+	// jumpFrom returns the step the program continues at after a jump of
+	// delta steps from the step at index idx
+	jumpFrom := func(idx, delta int) int {
+		target := idx + delta
+		if target < 0 {
+			target = 0
+		}
+		log.Printf("program %s jumps from step %d to step %d", p.Name, idx, target)
+		if target < len(steps) {
+			// the step jumped to does not wait for its soak time
+			ready[steps[target].Element] = time.Time{}
+		} else {
+			p.ClearProgress()
+		}
+		return target
+	}
+
+	for idx := first; idx < len(steps); idx++ {
+		step := steps[idx]
+		elem := elements[step.Element]
+		if prev != nil && (step.Overlap == 0 || skipped[step.Element] || time.Now().Before(ready[step.Element])) {
+			if !releasePrev() {
+				log.Printf("program %s is canceled", p.Name)
+				return
+			}
+		}
+		if skipped[step.Element] {
+			continue
+		}
+		if step.Volume > 0 && !ctrl.metered() {
+			log.Printf("program %s: element %d is skipped, there is no flow sensor to measure its volume", p.Name, step.Element)
+			continue
+		}
+		if !sleep(p.ctx, time.Until(ready[step.Element])) {
+			log.Printf("program %s is canceled", p.Name)
+			return
+		}
 		select {
-		case <-p.ctx.Done():
+		case delta := <-p.jumps:
+			// the jump asked between two steps skips the step before its
+			// zones are opened
+			idx = jumpFrom(idx, delta) - 1
+			continue
+		default:
+		}
+
+		p.checkpoint(&Progress{Step: idx, Remaining: step.Duration, Volume: step.Volume, Scale: scale})
+
+		zones := elem.Zones()
+		var err error
+		if prev != nil {
+			// the zones of the previous step are never released while
+			// waiting for a valve, the step does not overlap then
+			err = ctrl.tryOpenZones(p.ctx, p.Name, zones...)
+		} else {
+			err = ctrl.openZones(p.ctx, p.Name, zones...)
+		}
+		if err == TooManyValves && prev != nil {
+			if !releasePrev() {
+				log.Printf("program %s is canceled", p.Name)
+				return
+			}
+			err = ctrl.openZones(p.ctx, p.Name, zones...)
+		}
+		if err == TooManyValves {
+			log.Printf("program %s: element %d opens more valves than allowed, skipped", p.Name, step.Element)
+			continue
+		}
+		if err != nil {
 			log.Printf("program %s is canceled", p.Name)
-			if !t.Stop() {
-				<-t.C
+			return
+		}
+
+		// the watering starts when the zones are settled, the zones of the
+		// previous step are closed after the overlap
+		settle := step.Settle
+		if prev != nil {
+			settle -= step.Overlap
+			if !sleep(p.ctx, step.Overlap) {
+				ctrl.closeZones(zones...)
+				log.Printf("program %s is canceled", p.Name)
+				return
 			}
+			closePrev()
+		}
+		if !sleep(p.ctx, settle) {
+			ctrl.closeZones(zones...)
+			log.Printf("program %s is canceled", p.Name)
+			return
+		}
+
+		// the zones are kept open for the overlap with the next step
+		watered := step
+		keep := idx+1 < len(steps) && steps[idx+1].Overlap > 0 && step.Duration > steps[idx+1].Overlap
+		if keep {
+			cut := *step
+			cut.Duration -= steps[idx+1].Overlap
+			watered = &cut
+		}
+		err = p.water(elem, idx, watered, zones, scale)
+		if err == nil && keep {
+			prev, prevElem = zones, elem
+		} else {
+			ctrl.closeZones(zones...)
+		}
+		if delta, ok := err.(jump); ok {
+			idx = jumpFrom(idx, int(delta)) - 1
+			if !sleep(p.ctx, elem.gap(delay)) {
+				log.Printf("program %s is canceled", p.Name)
+				return
+			}
+			continue
+		}
+		if err == HighFlow || err == LowFlow {
+			if ctrl.flowMonitor().Action == FlowStop {
+				log.Printf("program %s is stopped, the flow of element %d is out of the tolerance", p.Name, step.Element)
+				return
+			}
+			log.Printf("program %s: element %d is skipped, its flow is out of the tolerance", p.Name, step.Element)
+			skipped[step.Element] = true
+		} else if err != nil {
+			log.Printf("program %s is canceled", p.Name)
+			return
+		}
+		ready[step.Element] = time.Now().Add(elem.Soak)
+		if idx+1 < len(steps) {
+			// the finished step is not repeated if the run is interrupted
+			next := steps[idx+1]
+			p.checkpoint(&Progress{Step: idx + 1, Remaining: next.Duration, Volume: next.Volume, Scale: scale})
+		} else {
+			// nothing is left to resume if the run is interrupted
+			p.ClearProgress()
+		}
+		if prev == nil && !sleep(p.ctx, elem.gap(delay)) {
+			log.Printf("program %s is canceled", p.Name)
 			return
-		case <-t.C:
-			// do nothing
 		}
-		elem.Device.TurnOff()
-		time.Sleep(1 * time.Second)
 	}
 	log.Printf("program %s is finished", p.Name)
 }
+
+// water keeps the zones of the step at index idx open until it is done
+func (p *Program) water(elem *ProgramElement, idx int, step *Step, zones []*Device, scale int) error {
+	// a volume based step runs until its volume is delivered, the duration
+	// of its element is the ceiling of the run time
+	duration := step.Duration
+	var delivered <-chan time.Time
+	if step.Volume > 0 {
+		duration = elem.ceiling()
+		ticker := time.NewTicker(volumePoll)
+		defer ticker.Stop()
+		delivered = ticker.C
+	}
+	t := time.NewTimer(duration)
+	defer t.Stop()
+
+	checkpoints := time.NewTicker(checkpointInterval)
+	defer checkpoints.Stop()
+	started := time.Now()
+
+	p.m.Lock()
+	p.stepStart = started
+	p.stepLength = step.Duration
+	p.m.Unlock()
+	defer func() {
+		p.m.Lock()
+		p.stepStart = time.Time{}
+		p.m.Unlock()
+	}()
+
+	// the metered flow is checked at the end of every measuring window
+	mon := ctrl.flowMonitor()
+	var check <-chan time.Time
+	if ctrl.metered() {
+		ticker := time.NewTicker(mon.Window)
+		defer ticker.Stop()
+		check = ticker.C
+	}
+
+	var expected float64
+	for _, dev := range zones {
+		flow := dev.GetFlow()
+		if flow == 0 {
+			expected = 0
+			break
+		}
+		expected += flow
+	}
+
+	start := ctrl.volume(zones...)
+	last := start
+	var measured float64
+	var windows int
+	// the progress is saved periodically and when the run is canceled
+	checkpoint := func() {
+		progress := &Progress{Step: idx, Remaining: duration - time.Since(started), Scale: scale}
+		if step.Volume > 0 {
+			progress.Volume = step.Volume - (ctrl.volume(zones...) - start)
+		}
+		if progress.Remaining < 0 {
+			progress.Remaining = 0
+		}
+		p.checkpoint(progress)
+	}
+	// a device without expected flow learns it if it is the only zone
+	learn := func() {
+		if expected == 0 && len(zones) == 1 && windows > 1 && measured > 0 {
+			flow := measured / (float64(windows-1) * mon.Window.Minutes())
+			log.Printf("device %s learned its flow: %.1f l/min", elem.Device.Name, flow)
+			elem.Device.SetFlow(flow)
+		}
+	}
+
+	for {
+		select {
+		case <-p.ctx.Done():
+			if p.keeping() {
+				checkpoint()
+			}
+			return p.ctx.Err()
+		case delta := <-p.jumps:
+			// the step is cut short by Next or Prev
+			return jump(delta)
+		case <-checkpoints.C:
+			checkpoint()
+		case <-t.C:
+			if step.Volume > 0 {
+				ctrl.event(&Event{Type: EventVolumeTimeout, Device: elem.DeviceName, Program: p.Name,
+					Message: fmt.Sprintf("device %s delivered %.1f liters of %.1f in %s", elem.DeviceName, ctrl.volume(zones...)-start, step.Volume, duration)})
+			}
+			learn()
+			return nil
+		case <-delivered:
+			if ctrl.volume(zones...)-start >= step.Volume {
+				learn()
+				return nil
+			}
+		case <-check:
+			volume := ctrl.volume(zones...)
+			flow := (volume - last) / mon.Window.Minutes()
+			last = volume
+			windows++
+			if windows == 1 {
+				// the first window lets the flow settle
+				continue
+			}
+			measured += flow * mon.Window.Minutes()
+			if err := p.checkFlow(elem, flow, expected, mon.Tolerance); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// checkFlow compares the measured flow of the element to the expected one,
+// the fault is recorded as an event
+func (p *Program) checkFlow(elem *ProgramElement, flow, expected float64, tolerance int) error {
+	if expected == 0 {
+		return nil
+	}
+
+	pct := flow * 100 / expected
+	switch {
+	case pct > float64(100+tolerance):
+		ctrl.event(&Event{Type: EventHighFlow, Device: elem.DeviceName, Program: p.Name,
+			Message: fmt.Sprintf("flow of device %s is %.1f l/min instead of %.1f l/min, broken head?", elem.DeviceName, flow, expected)})
+		return HighFlow
+	case pct < float64(100-tolerance):
+		ctrl.event(&Event{Type: EventLowFlow, Device: elem.DeviceName, Program: p.Name,
+			Message: fmt.Sprintf("flow of device %s is %.1f l/min instead of %.1f l/min, clogged or stuck valve?", elem.DeviceName, flow, expected)})
+		return LowFlow
+	}
+	return nil
+}
@@ -1,6 +1,7 @@
 package core_test
 
 import (
+	"sync"
 	"testing"
 	"time"
 
@@ -30,6 +31,60 @@ func TestProgramAddDelDevice(t *testing.T) {
 	assert.Empty(t, p.Elements)
 }
 
+// names returns the device names of the elements of the program
+func names(p *core.Program) []string {
+	n := []string{}
+	for _, e := range p.Elements {
+		n = append(n, e.DeviceName)
+	}
+	return n
+}
+
+func TestProgramEditElements(t *testing.T) {
+	gpioStub := NewGpioStub()
+	core.InitGpio(gpioStub)
+	d1 := &core.Device{Name: "dev1"}
+	d2 := &core.Device{Name: "dev2"}
+	d3 := &core.Device{Name: "dev3"}
+	master := &core.Device{Name: "master", Role: core.RoleMaster}
+	p := &core.Program{Name: "pr1"}
+	assert.Nil(t, p.AddDevice(d1, 1*time.Second))
+	assert.Nil(t, p.AddDevice(d2, 2*time.Second))
+
+	// insert
+	assert.Equal(t, core.OutOfRange, p.InsertElement(3, &core.ProgramElement{Device: d3}))
+	assert.Equal(t, core.OutOfRange, p.InsertElement(-1, &core.ProgramElement{Device: d3}))
+	assert.Nil(t, p.InsertElement(1, &core.ProgramElement{Device: d3, Duration: 3 * time.Second}))
+	assert.Equal(t, []string{"dev1", "dev3", "dev2"}, names(p))
+	assert.Nil(t, p.InsertElement(3, &core.ProgramElement{Device: d1}))
+	assert.Equal(t, []string{"dev1", "dev3", "dev2", "dev1"}, names(p))
+
+	// update
+	assert.Equal(t, core.OutOfRange, p.SetElement(4, &core.ProgramElement{Device: d3}))
+	assert.Equal(t, core.InvalidDuration, p.SetElement(0, &core.ProgramElement{Device: d1, Duration: -1}))
+	assert.Equal(t, core.DeviceIsMaster, p.SetElement(0, &core.ProgramElement{Device: master}))
+	assert.Nil(t, p.SetElement(3, &core.ProgramElement{Device: d3, Duration: 5 * time.Second}))
+	assert.Equal(t, []string{"dev1", "dev3", "dev2", "dev3"}, names(p))
+	assert.Equal(t, 5*time.Second, p.Elements[3].Duration)
+
+	// move
+	assert.Equal(t, core.OutOfRange, p.MoveElement(4, 0))
+	assert.Equal(t, core.OutOfRange, p.MoveElement(0, 4))
+	assert.Nil(t, p.MoveElement(2, 0))
+	assert.Equal(t, []string{"dev2", "dev1", "dev3", "dev3"}, names(p))
+	assert.Nil(t, p.MoveElement(0, 3))
+	assert.Equal(t, []string{"dev1", "dev3", "dev3", "dev2"}, names(p))
+
+	// replace, nothing is changed by an invalid element
+	assert.Equal(t, core.InvalidVolume, p.SetElements([]*core.ProgramElement{{Device: d2}, {Device: d1, Volume: -1}}))
+	assert.Equal(t, []string{"dev1", "dev3", "dev3", "dev2"}, names(p))
+	assert.Nil(t, p.SetElements([]*core.ProgramElement{{Device: d2}, {Device: d1, Parallel: []*core.Device{d3}}}))
+	assert.Equal(t, []string{"dev2", "dev1"}, names(p))
+	assert.Equal(t, []string{"dev3"}, p.Elements[1].ParallelNames)
+	assert.Nil(t, p.SetElements(nil))
+	assert.Empty(t, p.Elements)
+}
+
 func TestProgramStartStop(t *testing.T) {
 	gpioStub := NewGpioStub()
 	core.InitGpio(gpioStub)
@@ -101,3 +156,462 @@ func TestPrograms(t *testing.T) {
 		assert.Empty(t, *progs)
 	}
 }
+
+func TestProgramMasterDevice(t *testing.T) {
+	gpioStub := NewGpioStub()
+	core.InitGpio(gpioStub)
+	data := core.NewData()
+	m := &core.Device{Name: "master", Pin: 1, Role: core.RoleMaster, Lead: 300 * time.Millisecond, Lag: 300 * time.Millisecond}
+	d1 := &core.Device{Name: "dev1", Pin: 2}
+	assert.Nil(t, data.Devices.Add(m))
+	assert.Nil(t, data.Devices.Add(d1))
+
+	p := &core.Program{Name: "pr1"}
+	assert.Equal(t, core.DeviceIsMaster, p.AddDevice(m, 1*time.Second))
+	assert.Nil(t, p.AddDevice(d1, 1*time.Second))
+
+	p.Start()
+	time.Sleep(100 * time.Millisecond)
+	assert.True(t, m.IsOn())
+	assert.False(t, d1.IsOn())
+	time.Sleep(400 * time.Millisecond)
+	assert.True(t, m.IsOn())
+	assert.True(t, d1.IsOn())
+	time.Sleep(1000 * time.Millisecond)
+	assert.True(t, m.IsOn())
+	assert.False(t, d1.IsOn())
+	time.Sleep(300 * time.Millisecond)
+	assert.False(t, m.IsOn())
+
+	time.Sleep(600 * time.Millisecond)
+	p.Start()
+	time.Sleep(100 * time.Millisecond)
+	assert.True(t, m.IsOn())
+	p.Stop()
+	assert.False(t, d1.IsOn())
+	time.Sleep(400 * time.Millisecond)
+	assert.False(t, m.IsOn())
+
+	core.NewData()
+}
+
+func TestProgramParallelDevices(t *testing.T) {
+	gpioStub := NewGpioStub()
+	core.InitGpio(gpioStub)
+	data := core.NewData()
+	d1 := &core.Device{Name: "dev1", Pin: 1}
+	d2 := &core.Device{Name: "dev2", Pin: 2}
+	d3 := &core.Device{Name: "dev3", Pin: 3}
+	assert.Nil(t, data.Devices.Add(d1))
+	assert.Nil(t, data.Devices.Add(d2))
+	assert.Nil(t, data.Devices.Add(d3))
+	assert.Nil(t, data.Settings.Set(&core.Settings{MaxOpenValves: 2}))
+
+	p1 := &core.Program{Name: "pr1"}
+	assert.Nil(t, p1.AddDevice(d1, 1*time.Second, d2))
+	assert.Equal(t, []string{"dev2"}, p1.Elements[0].ParallelNames)
+	p2 := &core.Program{Name: "pr2"}
+	assert.Nil(t, p2.AddDevice(d3, 1*time.Second))
+	assert.Nil(t, data.Programs.Add(p1))
+	assert.Nil(t, data.Programs.Add(p2))
+	assert.True(t, data.Programs.IsDeviceInUse("dev2"))
+
+	// pr2 has to wait for the valves of pr1
+	p1.Start()
+	time.Sleep(100 * time.Millisecond)
+	p2.Start()
+	time.Sleep(100 * time.Millisecond)
+	assert.True(t, d1.IsOn())
+	assert.True(t, d2.IsOn())
+	assert.False(t, d3.IsOn())
+	time.Sleep(1000 * time.Millisecond)
+	assert.False(t, d1.IsOn())
+	assert.False(t, d2.IsOn())
+	assert.True(t, d3.IsOn())
+	p1.Stop()
+	p2.Stop()
+
+	// the element can never be started with this limit
+	assert.Nil(t, data.Settings.Set(&core.Settings{MaxOpenValves: 1}))
+	p1.Start()
+	time.Sleep(100 * time.Millisecond)
+	assert.False(t, d1.IsOn())
+	assert.False(t, d2.IsOn())
+	p1.Stop()
+
+	assert.Equal(t, core.InvalidSettings, data.Settings.Set(&core.Settings{MaxOpenValves: -1}))
+	core.NewData()
+}
+
+func TestProgramVolume(t *testing.T) {
+	gpioStub := NewGpioStub()
+	core.InitGpio(gpioStub)
+	data := core.NewData()
+	d1 := &core.Device{Name: "dev1", Pin: 1, Flow: 300}
+	d2 := &core.Device{Name: "dev2", Pin: 2}
+	assert.Nil(t, data.Devices.Add(d1))
+	assert.Nil(t, data.Devices.Add(d2))
+	assert.Nil(t, data.Sensors.Add(&core.Sensor{Name: "flow", Type: core.SensorFlow, Pin: 6, KFactor: 10}))
+	stop := gpioStub.pins[6].pulse(func() bool { return d1.IsOn() || d2.IsOn() })
+
+	p := &core.Program{Name: "pr1"}
+	assert.Equal(t, core.InvalidVolume, p.AddElement(&core.ProgramElement{Device: d1, Volume: -1}))
+	assert.Equal(t, core.InvalidVolume, p.AddElement(&core.ProgramElement{Device: d1, Volume: 10, Cycle: time.Minute}))
+	assert.Nil(t, p.AddElement(&core.ProgramElement{Device: d1, Volume: 2}))
+	assert.Nil(t, p.AddElement(&core.ProgramElement{Device: d2, Volume: 100, Duration: 500 * time.Millisecond}))
+	assert.Nil(t, data.Programs.Add(p))
+
+	// dev1 delivers 2 liters in 400ms at 300 l/min, the flow of dev2 is not
+	// known, it runs until its ceiling
+	steps := p.Timeline()
+	assert.Len(t, steps, 2)
+	assert.Equal(t, 400*time.Millisecond, steps[0].Duration)
+	assert.Equal(t, 2.0, steps[0].Volume)
+	assert.Equal(t, 500*time.Millisecond, steps[1].Duration)
+	assert.Equal(t, 100.0, steps[1].Volume)
+
+	p.Start()
+	time.Sleep(300 * time.Millisecond)
+	assert.True(t, d1.IsOn())
+	time.Sleep(500 * time.Millisecond)
+	assert.False(t, d1.IsOn())
+	time.Sleep(900 * time.Millisecond)
+	assert.True(t, d2.IsOn())
+	time.Sleep(600 * time.Millisecond)
+	assert.False(t, d2.IsOn())
+
+	rep := data.Usage.Report(time.Now(), time.Now())
+	assert.InDelta(t, 2.0, rep.Devices["dev1"], 0.5)
+	events := data.Events.Get(time.Time{})
+	assert.Len(t, events, 1)
+	assert.Equal(t, core.EventVolumeTimeout, events[0].Type)
+	assert.Equal(t, "dev2", events[0].Device)
+
+	stop()
+	data.Sensors.StopAll()
+	time.Sleep(100 * time.Millisecond)
+	core.NewData()
+}
+
+func TestProgramSuspendResume(t *testing.T) {
+	gpioStub := NewGpioStub()
+	core.InitGpio(gpioStub)
+	data := core.NewData()
+	d1 := &core.Device{Name: "dev1", Pin: 1}
+	d2 := &core.Device{Name: "dev2", Pin: 2}
+	assert.Nil(t, data.Devices.Add(d1))
+	assert.Nil(t, data.Devices.Add(d2))
+	p := &core.Program{Name: "pr1"}
+	assert.Nil(t, p.AddDevice(d1, 2*time.Second))
+	assert.Nil(t, p.AddDevice(d2, 1*time.Second))
+	assert.Nil(t, data.Programs.Add(p))
+
+	// the suspended program keeps the time its step has left
+	p.Start()
+	time.Sleep(500 * time.Millisecond)
+	data.Programs.SuspendAll()
+	assert.False(t, p.IsRunning())
+	assert.False(t, d1.IsOn())
+	if progress := p.GetProgress(); assert.NotNil(t, progress) {
+		assert.Equal(t, 0, progress.Step)
+		assert.InDelta(t, 1500*time.Millisecond, progress.Remaining, float64(100*time.Millisecond))
+		assert.Equal(t, 100, progress.Scale)
+	}
+
+	// it is resumed from there
+	p.Start()
+	time.Sleep(1000 * time.Millisecond)
+	assert.True(t, d1.IsOn())
+	time.Sleep(1800 * time.Millisecond)
+	assert.False(t, d1.IsOn())
+	assert.True(t, d2.IsOn())
+
+	// a stopped program starts from its first step
+	p.Stop()
+	assert.Nil(t, p.GetProgress())
+	p.Start()
+	time.Sleep(100 * time.Millisecond)
+	assert.True(t, d1.IsOn())
+	assert.NotNil(t, p.GetProgress())
+	p.Stop()
+	core.NewData()
+}
+
+func TestProgramPauseResume(t *testing.T) {
+	gpioStub := NewGpioStub()
+	core.InitGpio(gpioStub)
+	data := core.NewData()
+	d1 := &core.Device{Name: "dev1", Pin: 1}
+	d2 := &core.Device{Name: "dev2", Pin: 2}
+	assert.Nil(t, data.Devices.Add(d1))
+	assert.Nil(t, data.Devices.Add(d2))
+	p := &core.Program{Name: "pr1"}
+	assert.Nil(t, p.AddDevice(d1, 2*time.Second))
+	assert.Nil(t, p.AddDevice(d2, 1*time.Second))
+	assert.Nil(t, data.Programs.Add(p))
+
+	assert.Equal(t, core.NotRunning, p.Pause())
+	_, err := p.Resume("test")
+	assert.Equal(t, core.NotPaused, err)
+
+	// the paused program closes its valve and keeps the remaining time
+	p.Start()
+	time.Sleep(500 * time.Millisecond)
+	assert.Nil(t, p.Pause())
+	assert.True(t, p.IsPaused())
+	assert.False(t, p.IsRunning())
+	assert.False(t, d1.IsOn())
+	if progress := p.GetProgress(); assert.NotNil(t, progress) {
+		assert.Equal(t, 0, progress.Step)
+		assert.InDelta(t, 1500*time.Millisecond, progress.Remaining, float64(100*time.Millisecond))
+	}
+
+	// it continues from there
+	result, err := p.Resume("test")
+	assert.Nil(t, err)
+	assert.Equal(t, core.Started, result)
+	assert.False(t, p.IsPaused())
+	time.Sleep(1000 * time.Millisecond)
+	assert.True(t, d1.IsOn())
+	time.Sleep(1800 * time.Millisecond)
+	assert.False(t, d1.IsOn())
+	assert.True(t, d2.IsOn())
+
+	// a paused program is stopped for good
+	assert.Nil(t, p.Pause())
+	assert.Equal(t, 1, p.GetProgress().Step)
+	p.Stop()
+	assert.False(t, p.IsPaused())
+	assert.Nil(t, p.GetProgress())
+
+	// editing a paused program drops its progress, it is not paused anymore
+	p.Start()
+	time.Sleep(100 * time.Millisecond)
+	assert.Nil(t, p.Pause())
+	assert.Nil(t, p.AddDevice(d1, 1*time.Second))
+	assert.False(t, p.IsPaused())
+	assert.Nil(t, p.GetProgress())
+	_, err = p.Resume("test")
+	assert.Equal(t, core.NotPaused, err)
+	core.NewData()
+}
+
+func TestProgramNextPrev(t *testing.T) {
+	gpioStub := NewGpioStub()
+	core.InitGpio(gpioStub)
+	data := core.NewData()
+	d1 := &core.Device{Name: "dev1", Pin: 1}
+	d2 := &core.Device{Name: "dev2", Pin: 2}
+	assert.Nil(t, data.Devices.Add(d1))
+	assert.Nil(t, data.Devices.Add(d2))
+	p := &core.Program{Name: "pr1"}
+	assert.Nil(t, p.AddDevice(d1, 5*time.Second))
+	assert.Nil(t, p.AddDevice(d2, 5*time.Second))
+	assert.Nil(t, data.Programs.Add(p))
+
+	assert.Equal(t, core.NotRunning, p.Next())
+	assert.Equal(t, core.NotRunning, p.Prev())
+
+	// the step of dev1 is cut short
+	p.Start()
+	time.Sleep(300 * time.Millisecond)
+	assert.True(t, d1.IsOn())
+	assert.Nil(t, p.Next())
+	time.Sleep(100 * time.Millisecond)
+	assert.False(t, d1.IsOn())
+	assert.False(t, d2.IsOn())
+	time.Sleep(1100 * time.Millisecond)
+	assert.True(t, d2.IsOn())
+
+	// back to dev1
+	assert.Nil(t, p.Prev())
+	time.Sleep(1200 * time.Millisecond)
+	assert.True(t, d1.IsOn())
+	assert.False(t, d2.IsOn())
+
+	// the program finishes after skipping the last step
+	assert.Nil(t, p.Next())
+	time.Sleep(1200 * time.Millisecond)
+	assert.True(t, d2.IsOn())
+	assert.Nil(t, p.Next())
+	time.Sleep(1200 * time.Millisecond)
+	assert.False(t, d2.IsOn())
+	assert.False(t, p.IsRunning())
+	core.NewData()
+}
+
+func TestProgramNextBetweenSteps(t *testing.T) {
+	gpioStub := NewGpioStub()
+	core.InitGpio(gpioStub)
+	data := core.NewData()
+	d1 := &core.Device{Name: "dev1", Pin: 1}
+	d2 := &core.Device{Name: "dev2", Pin: 2}
+	d3 := &core.Device{Name: "dev3", Pin: 3}
+	for _, d := range []*core.Device{d1, d2, d3} {
+		assert.Nil(t, data.Devices.Add(d))
+	}
+	delay := 500 * time.Millisecond
+	p := &core.Program{Name: "pr1", Delay: &delay}
+	for _, d := range []*core.Device{d1, d2, d3} {
+		assert.Nil(t, p.AddDevice(d, 300*time.Millisecond))
+	}
+	assert.Nil(t, data.Programs.Add(p))
+
+	var m sync.Mutex
+	opened := false
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case <-time.After(5 * time.Millisecond):
+			}
+			m.Lock()
+			opened = opened || d2.IsOn()
+			m.Unlock()
+		}
+	}()
+
+	// the step of dev2 is skipped during the delay, its zone is not opened
+	p.Start()
+	time.Sleep(500 * time.Millisecond)
+	assert.False(t, d1.IsOn())
+	assert.Nil(t, p.Next())
+	time.Sleep(400 * time.Millisecond)
+	assert.True(t, d3.IsOn())
+	close(done)
+	m.Lock()
+	assert.False(t, opened)
+	m.Unlock()
+
+	p.Stop()
+	core.NewData()
+}
+
+func TestProgramStatus(t *testing.T) {
+	gpioStub := NewGpioStub()
+	core.InitGpio(gpioStub)
+	data := core.NewData()
+	d1 := &core.Device{Name: "dev1", Pin: 1}
+	d2 := &core.Device{Name: "dev2", Pin: 2}
+	assert.Nil(t, data.Devices.Add(d1))
+	assert.Nil(t, data.Devices.Add(d2))
+	p := &core.Program{Name: "pr1"}
+	assert.Nil(t, p.AddDevice(d1, 2*time.Second))
+	assert.Nil(t, p.AddDevice(d2, 1*time.Second))
+	assert.Nil(t, data.Programs.Add(p))
+
+	assert.Equal(t, &core.ProgramStatus{State: core.StateIdle}, p.Status())
+
+	// the first element is on
+	p.StartBy("test")
+	time.Sleep(500 * time.Millisecond)
+	st := p.Status()
+	assert.Equal(t, core.StateRunning, st.State)
+	assert.Equal(t, "test", st.Source)
+	assert.NotNil(t, st.Started)
+	if assert.NotNil(t, st.Step) && assert.NotNil(t, st.Element) {
+		assert.Equal(t, 0, *st.Step)
+		assert.Equal(t, 0, *st.Element)
+	}
+	assert.Equal(t, []string{"dev1"}, st.Devices)
+	assert.InDelta(t, 500*time.Millisecond, st.Elapsed, float64(100*time.Millisecond))
+	assert.InDelta(t, 1500*time.Millisecond, st.Remaining, float64(100*time.Millisecond))
+	assert.InDelta(t, 3500*time.Millisecond, st.TotalRemaining, float64(100*time.Millisecond))
+
+	// the second one after the delay between the zones
+	time.Sleep(2800 * time.Millisecond)
+	st = p.Status()
+	if assert.NotNil(t, st.Step) {
+		assert.Equal(t, 1, *st.Step)
+	}
+	assert.Equal(t, []string{"dev2"}, st.Devices)
+
+	// the paused program keeps its step
+	assert.Nil(t, p.Pause())
+	st = p.Status()
+	assert.Equal(t, core.StatePaused, st.State)
+	if assert.NotNil(t, st.Step) {
+		assert.Equal(t, 1, *st.Step)
+	}
+	p.Stop()
+	assert.Equal(t, core.StateIdle, p.Status().State)
+	core.NewData()
+}
+
+func TestProgramDelayOverlap(t *testing.T) {
+	gpioStub := NewGpioStub()
+	core.InitGpio(gpioStub)
+	data := core.NewData()
+	d1 := &core.Device{Name: "dev1", Pin: 1}
+	d2 := &core.Device{Name: "dev2", Pin: 2}
+	assert.Nil(t, data.Devices.Add(d1))
+	assert.Nil(t, data.Devices.Add(d2))
+	p := &core.Program{Name: "pr1"}
+	assert.Nil(t, p.AddDevice(d1, 1*time.Second))
+	assert.Nil(t, p.AddDevice(d2, 1*time.Second))
+	assert.Nil(t, data.Programs.Add(p))
+
+	// the delay of the program is longer than the default one
+	delay := 2 * time.Second
+	assert.Nil(t, p.SetDelay(&delay, 0))
+	p.Start()
+	time.Sleep(1500 * time.Millisecond)
+	assert.False(t, d1.IsOn())
+	assert.False(t, d2.IsOn())
+	time.Sleep(1800 * time.Millisecond)
+	assert.True(t, d2.IsOn())
+
+	// the delay is canceled by stopping the program
+	time.Sleep(1200 * time.Millisecond)
+	assert.False(t, d2.IsOn())
+	assert.True(t, p.IsRunning())
+	stopped := time.Now()
+	p.Stop()
+	assert.True(t, time.Since(stopped) < 100*time.Millisecond)
+	assert.False(t, p.IsRunning())
+
+	// dev2 opens before dev1 closes
+	assert.Nil(t, p.SetDelay(nil, 300*time.Millisecond))
+	p.Start()
+	time.Sleep(850 * time.Millisecond)
+	assert.True(t, d1.IsOn())
+	assert.True(t, d2.IsOn())
+	time.Sleep(350 * time.Millisecond)
+	assert.False(t, d1.IsOn())
+	assert.True(t, d2.IsOn())
+	time.Sleep(1000 * time.Millisecond)
+	assert.False(t, d2.IsOn())
+	p.Stop()
+	core.NewData()
+}
+
+func TestProgramOverlapValveLimit(t *testing.T) {
+	gpioStub := NewGpioStub()
+	core.InitGpio(gpioStub)
+	data := core.NewData()
+	d1 := &core.Device{Name: "dev1", Pin: 1}
+	d2 := &core.Device{Name: "dev2", Pin: 2}
+	assert.Nil(t, data.Devices.Add(d1))
+	assert.Nil(t, data.Devices.Add(d2))
+	assert.Nil(t, data.Settings.Set(&core.Settings{MaxOpenValves: 1}))
+	p := &core.Program{Name: "pr1", Overlap: 100 * time.Millisecond}
+	assert.Nil(t, p.AddDevice(d1, 300*time.Millisecond))
+	assert.Nil(t, p.AddDevice(d2, 300*time.Millisecond))
+	assert.Nil(t, data.Programs.Add(p))
+
+	// the zones can not be open together, dev2 opens after the delay
+	p.Start()
+	time.Sleep(100 * time.Millisecond)
+	assert.True(t, d1.IsOn())
+	assert.False(t, d2.IsOn())
+	time.Sleep(1300 * time.Millisecond)
+	assert.False(t, d1.IsOn())
+	assert.True(t, d2.IsOn())
+	time.Sleep(1600 * time.Millisecond)
+	assert.False(t, d2.IsOn())
+	assert.False(t, p.IsRunning())
+	p.Stop()
+	core.NewData()
+}
@@ -0,0 +1,166 @@
+package core
+
+import (
+	"errors"
+	"log"
+	"sync"
+	"time"
+)
+
+var (
+	ProgramRunning      = errors.New("Program is already running")
+	OtherProgramRunning = errors.New("Another program is running")
+	QueueOutOfRange     = errors.New("Queue index out of range")
+)
+
+// policies of the programs started while they or, with serialized programs,
+// any other program is running
+const (
+	OverlapSkip    = "skip"
+	OverlapQueue   = "queue"
+	OverlapPreempt = "preempt"
+)
+
+// results of submitting a program
+const (
+	Started   = "started"
+	Queued    = "queued"
+	Preempted = "preempted"
+)
+
+// QueueEntry is a program waiting for the running programs to finish
+type QueueEntry struct {
+	Program string    `json:"program"`
+	Source  string    `json:"source"`
+	Time    time.Time `json:"time"`
+	prog    *Program
+}
+
+// Queue starts the submitted programs according to the overlap policy, the
+// queued programs are started in order as soon as they are allowed to run
+type Queue struct {
+	Entries    []*QueueEntry `json:"entries"`
+	preempting bool
+	m          sync.Mutex
+}
+
+func NewQueue() *Queue {
+	return &Queue{Entries: []*QueueEntry{}}
+}
+
+// Submit starts the program now if it is not running and, with serialized
+// programs, no other program is running, otherwise the overlap policy
+// decides: the program is skipped, queued or the running ones are stopped,
+// source tells who started the program
+func (q *Queue) Submit(prog *Program, source string) (string, error) {
+	policy, serial := ctrl.overlapPolicy()
+
+	q.m.Lock()
+	busy := q.busy(prog, serial)
+	if len(busy) == 0 {
+		defer q.m.Unlock()
+		log.Printf("program %s is started by %s", prog.Name, source)
+		prog.StartBy(source)
+		return Started, nil
+	}
+
+	switch policy {
+	case OverlapQueue:
+		defer q.m.Unlock()
+		log.Printf("program %s is queued by %s", prog.Name, source)
+		q.Entries = append(q.Entries, &QueueEntry{Program: prog.Name, Source: source, Time: time.Now(), prog: prog})
+		return Queued, nil
+	case OverlapPreempt:
+		q.preempting = true
+		q.m.Unlock()
+
+		for _, p := range busy {
+			log.Printf("program %s is preempted by %s", p.Name, source)
+			p.Stop()
+		}
+
+		q.m.Lock()
+		defer q.m.Unlock()
+		q.preempting = false
+		log.Printf("program %s is started by %s", prog.Name, source)
+		prog.StartBy(source)
+		return Preempted, nil
+	}
+
+	q.m.Unlock()
+	if busy[0] == prog {
+		return "", ProgramRunning
+	}
+	return "", OtherProgramRunning
+}
+
+// busy returns the running programs preventing prog from starting
+func (q *Queue) busy(prog *Program, serial bool) []*Program {
+	if prog.IsRunning() {
+		return []*Program{prog}
+	}
+	if serial {
+		return ctrl.runningPrograms()
+	}
+	return nil
+}
+
+// next starts the queued programs allowed to run, it is called when a
+// program finishes
+func (q *Queue) next() {
+	_, serial := ctrl.overlapPolicy()
+
+	q.m.Lock()
+	defer q.m.Unlock()
+
+	for !q.preempting && len(q.Entries) != 0 {
+		entry := q.Entries[0]
+		if len(q.busy(entry.prog, serial)) != 0 {
+			return
+		}
+		q.Entries = q.Entries[1:]
+		log.Printf("queued program %s is started", entry.Program)
+		entry.prog.StartBy(entry.Source)
+	}
+}
+
+// List returns the queued programs in order
+func (q *Queue) List() []*QueueEntry {
+	q.m.Lock()
+	defer q.m.Unlock()
+
+	return append([]*QueueEntry{}, q.Entries...)
+}
+
+// Del removes the queued program at the index idx
+func (q *Queue) Del(idx int) error {
+	q.m.Lock()
+	defer q.m.Unlock()
+
+	if idx < 0 || idx >= len(q.Entries) {
+		return QueueOutOfRange
+	}
+	q.Entries = append(q.Entries[:idx:idx], q.Entries[idx+1:]...)
+	return nil
+}
+
+// Cancel removes every queued run of the program
+func (q *Queue) Cancel(prog *Program) {
+	q.m.Lock()
+	defer q.m.Unlock()
+
+	entries := []*QueueEntry{}
+	for _, entry := range q.Entries {
+		if entry.prog != prog {
+			entries = append(entries, entry)
+		}
+	}
+	q.Entries = entries
+}
+
+func (q *Queue) Clear() {
+	q.m.Lock()
+	defer q.m.Unlock()
+
+	q.Entries = []*QueueEntry{}
+}
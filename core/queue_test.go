@@ -0,0 +1,96 @@
+package core_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/peter-vaczi/sprinkler/core"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueue(t *testing.T) {
+	gpioStub := NewGpioStub()
+	core.InitGpio(gpioStub)
+	data := core.NewData()
+	d1 := &core.Device{Name: "dev1", Pin: 1}
+	d2 := &core.Device{Name: "dev2", Pin: 2}
+	assert.Nil(t, data.Devices.Add(d1))
+	assert.Nil(t, data.Devices.Add(d2))
+
+	p1 := &core.Program{Name: "pr1"}
+	p2 := &core.Program{Name: "pr2"}
+	assert.Nil(t, p1.AddDevice(d1, 1*time.Second))
+	assert.Nil(t, p2.AddDevice(d2, 1*time.Second))
+	assert.Nil(t, data.Programs.Add(p1))
+	assert.Nil(t, data.Programs.Add(p2))
+
+	// programs run concurrently and a running program is skipped by default
+	result, err := data.Queue.Submit(p1, "test")
+	assert.Nil(t, err)
+	assert.Equal(t, core.Started, result)
+	_, err = data.Queue.Submit(p1, "test")
+	assert.Equal(t, core.ProgramRunning, err)
+	result, err = data.Queue.Submit(p2, "test")
+	assert.Nil(t, err)
+	assert.Equal(t, core.Started, result)
+	time.Sleep(100 * time.Millisecond)
+	assert.True(t, d1.IsOn())
+	assert.True(t, d2.IsOn())
+	data.Programs.StopAll()
+
+	// serialized programs are skipped while another one is running
+	assert.Nil(t, data.Settings.Set(&core.Settings{Serialize: true}))
+	_, err = data.Queue.Submit(p1, "test")
+	assert.Nil(t, err)
+	_, err = data.Queue.Submit(p2, "test")
+	assert.Equal(t, core.OtherProgramRunning, err)
+	data.Programs.StopAll()
+
+	// the queued programs start in order when the running one finishes
+	assert.Nil(t, data.Settings.Set(&core.Settings{OverlapPolicy: core.OverlapQueue, Serialize: true}))
+	_, err = data.Queue.Submit(p1, "test")
+	assert.Nil(t, err)
+	result, err = data.Queue.Submit(p2, "schedule sc2")
+	assert.Nil(t, err)
+	assert.Equal(t, core.Queued, result)
+	result, err = data.Queue.Submit(p1, "schedule sc1")
+	assert.Nil(t, err)
+	assert.Equal(t, core.Queued, result)
+	entries := data.Queue.List()
+	if assert.Equal(t, 2, len(entries)) {
+		assert.Equal(t, "pr2", entries[0].Program)
+		assert.Equal(t, "schedule sc2", entries[0].Source)
+		assert.Equal(t, "pr1", entries[1].Program)
+	}
+	assert.Equal(t, core.QueueOutOfRange, data.Queue.Del(2))
+
+	time.Sleep(500 * time.Millisecond)
+	assert.True(t, d1.IsOn())
+	assert.False(t, d2.IsOn())
+	// pr1 finishes after its step and the zone delay
+	time.Sleep(2 * time.Second)
+	assert.False(t, d1.IsOn())
+	assert.True(t, d2.IsOn())
+	assert.Equal(t, 1, len(data.Queue.List()))
+	data.Queue.Cancel(p1)
+	assert.Empty(t, data.Queue.List())
+	data.Programs.StopAll()
+
+	// the running programs are stopped by a preempting one
+	assert.Nil(t, data.Settings.Set(&core.Settings{OverlapPolicy: core.OverlapPreempt, Serialize: true}))
+	_, err = data.Queue.Submit(p1, "test")
+	assert.Nil(t, err)
+	time.Sleep(100 * time.Millisecond)
+	result, err = data.Queue.Submit(p2, "test")
+	assert.Nil(t, err)
+	assert.Equal(t, core.Preempted, result)
+	time.Sleep(100 * time.Millisecond)
+	assert.False(t, p1.IsRunning())
+	assert.False(t, d1.IsOn())
+	assert.True(t, p2.IsRunning())
+	assert.True(t, d2.IsOn())
+	data.Programs.StopAll()
+
+	assert.Equal(t, core.InvalidSettings, data.Settings.Set(&core.Settings{OverlapPolicy: "later"}))
+	core.NewData()
+}
@@ -2,13 +2,30 @@ package core
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/robfig/cron"
 )
 
+var (
+	InvalidSpec        = errors.New("Invalid schedule spec")
+	InvalidActiveRange = errors.New("Invalid active range, expected MM-DD")
+	InvalidSkipCount   = errors.New("Invalid number of runs to skip")
+)
+
+// activeLayout is the format of the first and last days of the active range
+const activeLayout = "01-02"
+
+// Schedule starts its program at the times of its spec, between the ActiveFrom
+// and ActiveTo days of every year if they are set, LastRun is the time of the
+// last run the schedule handled or the time it was enabled, the next SkipNext
+// runs and the runs before PausedUntil do not start the program
 type Schedule struct {
 	Name        string        `json:"name"`
 	ProgramName string        `json:"program"`
@@ -16,11 +33,22 @@ type Schedule struct {
 	Spec        string        `json:"spec"`
 	Sched       cron.Schedule `json:"-"`
 	Enabled     bool          `json:"enabled"`
+	ActiveFrom  string        `json:"active-from,omitempty"`
+	ActiveTo    string        `json:"active-to,omitempty"`
+	Skipped     *Skip         `json:"skipped,omitempty"`
+	LastRun     *time.Time    `json:"last-run,omitempty"`
+	SkipNext    int           `json:"skip-next,omitempty"`
+	PausedUntil *time.Time    `json:"paused-until,omitempty"`
 	m           sync.Mutex
-	ctx         context.Context
 	cancel      context.CancelFunc
 }
 
+// Skip is a run of a schedule which did not start its program
+type Skip struct {
+	Time   time.Time `json:"time"`
+	Reason string    `json:"reason"`
+}
+
 type Schedules map[string]*Schedule
 
 func NewSchedules() *Schedules {
@@ -29,6 +57,9 @@ func NewSchedules() *Schedules {
 }
 
 func (s *Schedules) Add(sched *Schedule) error {
+	mapsM.Lock()
+	defer mapsM.Unlock()
+
 	if _, exists := (*s)[sched.Name]; exists {
 		return AlreadyExists
 	}
@@ -38,13 +69,26 @@ func (s *Schedules) Add(sched *Schedule) error {
 	if err != nil {
 		return err
 	}
+	if isPast(sched.Sched, time.Now()) {
+		return TimeInPast
+	}
+	err = sched.SetActive(sched.ActiveFrom, sched.ActiveTo)
+	if err != nil {
+		return err
+	}
 
 	(*s)[sched.Name] = sched
+	if sched.Enabled {
+		sched.Enable()
+	}
 
 	return nil
 }
 
 func (s *Schedules) Get(name string) (*Schedule, error) {
+	mapsM.RLock()
+	defer mapsM.RUnlock()
+
 	if sched, exists := (*s)[name]; exists {
 		return sched, nil
 	}
@@ -53,7 +97,11 @@ func (s *Schedules) Get(name string) (*Schedule, error) {
 }
 
 func (s *Schedules) Del(name string) error {
-	if _, exists := (*s)[name]; exists {
+	mapsM.Lock()
+	defer mapsM.Unlock()
+
+	if sched, exists := (*s)[name]; exists {
+		sched.kill()
 		delete(*s, name)
 		return nil
 	}
@@ -61,13 +109,45 @@ func (s *Schedules) Del(name string) error {
 	return NotFound
 }
 
+// List returns the schedules in no particular order
+func (s *Schedules) List() []*Schedule {
+	mapsM.RLock()
+	defer mapsM.RUnlock()
+
+	schs := make([]*Schedule, 0, len(*s))
+	for _, sc := range *s {
+		schs = append(schs, sc)
+	}
+	return schs
+}
+
+// MarshalJSON encodes the schedules while holding the lock of the maps
+func (s *Schedules) MarshalJSON() ([]byte, error) {
+	type schedules Schedules
+
+	mapsM.RLock()
+	defer mapsM.RUnlock()
+
+	return json.Marshal((*schedules)(s))
+}
+
 func (s *Schedules) Set(name string, newSch *Schedule) error {
-	if sch, exists := (*s)[name]; exists {
+	if sch, err := s.Get(name); err == nil {
+		if newSch.Spec != sch.Spec {
+			sc, err := sch.parseSpec(newSch.Spec)
+			if err == nil && isPast(sc, time.Now()) {
+				return TimeInPast
+			}
+		}
 		sch.SetProgram(newSch.Program)
 		err := sch.SetSpec(newSch.Spec)
 		if err != nil {
 			return err
 		}
+		err = sch.SetActive(newSch.ActiveFrom, newSch.ActiveTo)
+		if err != nil {
+			return err
+		}
 		if newSch.Enabled {
 			sch.Enable()
 		} else {
@@ -80,11 +160,40 @@ func (s *Schedules) Set(name string, newSch *Schedule) error {
 }
 
 func (s *Schedules) DisableAll() {
-	for _, sc := range *s {
+	for _, sc := range s.List() {
 		sc.Disable()
 	}
 }
 
+// StopAll cancels the timers of the schedules keeping them enabled, they are
+// armed again by LoadState
+func (s *Schedules) StopAll() {
+	for _, sc := range s.List() {
+		sc.kill()
+	}
+}
+
+// CatchUp handles the runs of the enabled schedules missed since their last
+// run, e.g. while the daemon was down
+func (s *Schedules) CatchUp(now time.Time) {
+	for _, sc := range s.List() {
+		if sc.IsEnabled() {
+			sc.catchUp(now, ctrl.catchUp())
+		}
+	}
+}
+
+// MarshalJSON encodes the schedule while holding its lock, its runs are
+// recorded by its timer
+func (s *Schedule) MarshalJSON() ([]byte, error) {
+	type schedule Schedule
+
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	return json.Marshal((*schedule)(s))
+}
+
 func (s *Schedule) SetProgram(prog *Program) {
 	s.Program = prog
 	if prog != nil {
@@ -92,8 +201,12 @@ func (s *Schedule) SetProgram(prog *Program) {
 	}
 }
 
+// SetSpec sets the scheduling specification, it is a standard cron spec, a
+// sun spec: @sunrise|@sunset [offset] [finish], a day spec: @interval,
+// @odd, @even or @weekdays, or a one-shot spec: @once <YYYY-MM-DD> <HH:MM>
+// [delete] or @once <RFC3339 time> [delete]
 func (s *Schedule) SetSpec(spec string) error {
-	sc, err := cron.ParseStandard(spec)
+	sc, err := s.parseSpec(spec)
 	if err != nil {
 		return err
 	}
@@ -102,17 +215,278 @@ func (s *Schedule) SetSpec(spec string) error {
 	return nil
 }
 
+func (s *Schedule) parseSpec(spec string) (cron.Schedule, error) {
+	fields := strings.Fields(spec)
+	if len(fields) == 0 {
+		return cron.ParseStandard(spec)
+	}
+
+	switch fields[0] {
+	case Sunrise, Sunset:
+		sc, err := parseSunSpec(spec, s.duration)
+		if err != nil {
+			return nil, err
+		}
+		return sc, nil
+	case Interval, OddDays, EvenDays, Weekdays:
+		sc, err := parseDaySpec(spec)
+		if err != nil {
+			return nil, err
+		}
+		return sc, nil
+	case Once:
+		sc, err := parseOnceSpec(spec)
+		if err != nil {
+			return nil, err
+		}
+		return sc, nil
+	}
+	return cron.ParseStandard(spec)
+}
+
+// SetActive sets the first and last days of the year the schedule is active
+// on in MM-DD format, the range may span the new year, empty days mean the
+// start and the end of the year
+func (s *Schedule) SetActive(from, to string) error {
+	for _, day := range []string{from, to} {
+		if _, err := time.Parse(activeLayout, day); day != "" && err != nil {
+			return InvalidActiveRange
+		}
+	}
+
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	s.ActiveFrom = from
+	s.ActiveTo = to
+	return nil
+}
+
+// GetNext returns the time of the next run, the zero time if there is none
 func (s *Schedule) GetNext() time.Time {
-	return s.Sched.Next(time.Now())
+	return s.next(time.Now())
+}
+
+// next returns the first run after the time t inside the active range, the
+// runs inside blackout windows are shifted to their end by the shift policy
+func (s *Schedule) next(t time.Time) time.Time {
+	next := s.Sched.Next(t)
+	for i := 0; i < 3 && !next.IsZero() && !s.isActive(next); i++ {
+		next = s.Sched.Next(s.activeStart(next).Add(-time.Second))
+	}
+	if next.IsZero() || !s.isActive(next) {
+		return time.Time{}
+	}
+
+	if ctrl.blackoutPolicy() == BlackoutShift {
+		if _, end, found := ctrl.blackout(next); found {
+			return end
+		}
+	}
+	return next
 }
 
+// isActive returns true if the day of the time t is inside the active range
+func (s *Schedule) isActive(t time.Time) bool {
+	from, to := s.activeRange()
+	day := int(t.Month())*100 + t.Day()
+	if from <= to {
+		return from <= day && day <= to
+	}
+	return day >= from || day <= to
+}
+
+// activeStart returns the first day of the next active range after the
+// time t
+func (s *Schedule) activeStart(t time.Time) time.Time {
+	from, _ := s.activeRange()
+	start := time.Date(t.Year(), time.Month(from/100), from%100, 0, 0, 0, 0, t.Location())
+	if !start.After(t) {
+		start = start.AddDate(1, 0, 0)
+	}
+	return start
+}
+
+// activeRange returns the first and last days of the active range as
+// month*100+day
+func (s *Schedule) activeRange() (int, int) {
+	from, to := 101, 1231
+	if day, err := time.Parse(activeLayout, s.ActiveFrom); err == nil {
+		from = int(day.Month())*100 + day.Day()
+	}
+	if day, err := time.Parse(activeLayout, s.ActiveTo); err == nil {
+		to = int(day.Month())*100 + day.Day()
+	}
+	return from, to
+}
+
+// GetSkipped returns the last run which did not start the program
+func (s *Schedule) GetSkipped() *Skip {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	return s.Skipped
+}
+
+// skip records the reason the run at the time t did not start the program
+func (s *Schedule) skip(t time.Time, reason string) {
+	log.Printf("schedule %s is skipped, %s", s.Name, reason)
+
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	s.Skipped = &Skip{Time: t, Reason: reason}
+}
+
+// SkipRuns makes the schedule skip its next n runs, 0 cancels the pending
+// skips
+func (s *Schedule) SkipRuns(n int) error {
+	if n < 0 {
+		return InvalidSkipCount
+	}
+
+	s.m.Lock()
+	s.SkipNext = n
+	s.m.Unlock()
+
+	ctrl.storeState()
+	return nil
+}
+
+// GetSkipNext returns the number of the next runs to be skipped
+func (s *Schedule) GetSkipNext() int {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	return s.SkipNext
+}
+
+// Pause makes the schedule skip its runs until the given time
+func (s *Schedule) Pause(until time.Time) {
+	s.m.Lock()
+	s.PausedUntil = &until
+	s.m.Unlock()
+
+	ctrl.storeState()
+}
+
+// Unpause cancels the pause of the schedule
+func (s *Schedule) Unpause() {
+	s.m.Lock()
+	s.PausedUntil = nil
+	s.m.Unlock()
+
+	ctrl.storeState()
+}
+
+// GetPaused returns the end of the pause if it is active at the time t
+func (s *Schedule) GetPaused(t time.Time) (time.Time, bool) {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	if s.PausedUntil == nil || !t.Before(*s.PausedUntil) {
+		return time.Time{}, false
+	}
+	return *s.PausedUntil, true
+}
+
+// held returns the reason the run at the time t is skipped by the pause or
+// the pending skips of the schedule, a pending skip is used up
+func (s *Schedule) held(t time.Time) (string, bool) {
+	if until, paused := s.GetPaused(t); paused {
+		return fmt.Sprintf("paused until %s", until.Format(time.RFC1123)), true
+	}
+
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	if s.SkipNext == 0 {
+		return "", false
+	}
+	s.SkipNext--
+	return fmt.Sprintf("skipped on request, %d more to skip", s.SkipNext), true
+}
+
+// GetLastRun returns the time of the last run the schedule handled
+func (s *Schedule) GetLastRun() *time.Time {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	return s.LastRun
+}
+
+// catchUp starts the program for the latest run missed since the last run if
+// it was missed by at most the grace window, the missed runs are skipped
+// otherwise
+func (s *Schedule) catchUp(now time.Time, grace time.Duration) {
+	last := s.GetLastRun()
+	if once, ok := s.Sched.(*onceSchedule); ok && last == nil {
+		// the one-shot schedules have no run before
+		before := once.at.Add(-time.Second)
+		last = &before
+	}
+	if last == nil || s.Program == nil {
+		return
+	}
+	first := s.next(*last)
+	if first.IsZero() || first.After(now) {
+		return
+	}
+
+	// only the runs inside the grace window may be started
+	from := *last
+	if start := now.Add(-grace); start.After(from) {
+		from = start
+	}
+	var missed time.Time
+	for next := s.next(from); !next.IsZero() && !next.After(now); next = s.next(next) {
+		missed = next
+	}
+	if missed.IsZero() {
+		s.skip(first, fmt.Sprintf("missed by more than the catch-up window of %s", grace))
+		s.m.Lock()
+		s.LastRun = &now
+		s.m.Unlock()
+		s.expire()
+		ctrl.storeState()
+		return
+	}
+
+	log.Printf("schedule %s is catching up with the run missed at %s", s.Name, missed)
+	s.fire(missed, "catch-up of schedule "+s.Name)
+}
+
+func (s *Schedule) IsEnabled() bool {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	return s.Enabled
+}
+
+// duration returns the run time of the program of the schedule
+func (s *Schedule) duration() time.Duration {
+	if s.Program == nil {
+		return 0
+	}
+	return s.Program.Duration()
+}
+
+// Enable arms the schedule, the runs missed from the time it is enabled are
+// caught up after a downtime
 func (s *Schedule) Enable() {
+	s.kill()
+
 	s.m.Lock()
+	if !s.Enabled || s.LastRun == nil {
+		now := time.Now()
+		s.LastRun = &now
+	}
 	s.Enabled = true
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
 	s.m.Unlock()
 
-	s.kill()
-	go s.run()
+	go s.run(ctx)
 }
 
 func (s *Schedule) Disable() {
@@ -132,25 +506,74 @@ func (s *Schedule) kill() {
 	s.m.Unlock()
 }
 
-func (s *Schedule) run() {
-	s.m.Lock()
+func (s *Schedule) run(ctx context.Context) {
 	next := s.GetNext()
-	prog := s.Program
-	s.ctx, s.cancel = context.WithCancel(context.Background())
-	s.m.Unlock()
+
+	if s.Program == nil {
+		log.Printf("schedule %s has no program", s.Name)
+		return
+	}
+	if next.IsZero() {
+		log.Printf("schedule %s has no next run", s.Name)
+		return
+	}
 
 	log.Printf("schedule %s will start program %s at %s", s.Name, s.Program.Name, next)
 	t := time.NewTimer(next.Sub(time.Now()))
 	select {
-	case <-s.ctx.Done():
+	case <-ctx.Done():
 		log.Printf("schedule %s is canceled", s.Name)
 		if !t.Stop() {
 			<-t.C
 		}
 		return
 	case <-t.C:
-		log.Printf("schedule %s is starting program %s now", s.Name, s.Program.Name)
-		prog.Start()
-		go s.run()
+		s.fire(next, "schedule "+s.Name)
+		if s.IsEnabled() {
+			go s.run(ctx)
+		}
+	}
+}
+
+// fire starts the program for the run at the time t unless the pause or the
+// pending skips of the schedule, the rain delay, a rain sensor, a blackout
+// window or the overlap policy prevents it, the run is recorded as the last
+// one
+func (s *Schedule) fire(t time.Time, source string) {
+	s.m.Lock()
+	prog := s.Program
+	s.LastRun = &t
+	s.m.Unlock()
+
+	if reason, held := s.held(t); held {
+		s.skip(t, reason)
+	} else if until, delayed := ctrl.rainDelay(time.Now()); delayed {
+		s.skip(t, fmt.Sprintf("rain delay until %s", until.Format(time.RFC1123)))
+	} else if sensor, blocked := ctrl.blockingSensor(); blocked {
+		s.skip(t, fmt.Sprintf("sensor %s is active", sensor))
+	} else if name, end, found := ctrl.blackout(time.Now()); found {
+		s.skip(t, fmt.Sprintf("blackout %s until %s", name, end.Format(time.RFC1123)))
+	} else if _, err := ctrl.submit(prog, source); err != nil {
+		s.skip(t, fmt.Sprintf("program %s not started: %s", prog.Name, strings.ToLower(err.Error())))
+	}
+	s.expire()
+	ctrl.storeState()
+}
+
+// expire disables a one-shot schedule after its run, it is deleted if its
+// spec says so
+func (s *Schedule) expire() {
+	once, ok := s.Sched.(*onceSchedule)
+	if !ok {
+		return
+	}
+
+	s.m.Lock()
+	s.Enabled = false
+	s.m.Unlock()
+
+	if once.remove {
+		log.Printf("one-shot schedule %s is deleted", s.Name)
+		ctrl.delSchedule(s.Name)
 	}
 }
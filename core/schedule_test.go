@@ -114,3 +114,110 @@ func TestScheduleEnableDisable(t *testing.T) {
 	p.DelDevice(0)
 	p.DelDevice(0)
 }
+
+func TestScheduleCatchUp(t *testing.T) {
+	gpioStub := NewGpioStub()
+	core.InitGpio(gpioStub)
+	data := core.NewData()
+	d1 := &core.Device{Name: "dev1", Pin: 1}
+	assert.Nil(t, data.Devices.Add(d1))
+	p := &core.Program{Name: "pr1"}
+	assert.Nil(t, p.AddDevice(d1, 1*time.Second))
+	assert.Nil(t, data.Programs.Add(p))
+
+	now := time.Date(2026, 10, 18, 6, 30, 0, 0, time.Local)
+	last := time.Date(2026, 10, 17, 6, 0, 0, 0, time.Local)
+	s := &core.Schedule{Name: "sc1", Spec: "0 6 * * *", Program: p, Enabled: true}
+	assert.Nil(t, data.Schedules.Add(s))
+
+	// the runs are missed from the time the schedule is enabled
+	if assert.NotNil(t, s.GetLastRun()) {
+		assert.WithinDuration(t, time.Now(), *s.GetLastRun(), time.Second)
+	}
+	s.Disable()
+	s.LastRun = &last
+	s.Enable()
+	assert.True(t, s.GetLastRun().After(last))
+
+	// the missed run is skipped without a catch-up window
+	s.LastRun = &last
+	data.Schedules.CatchUp(now)
+	assert.False(t, p.IsRunning())
+	if assert.NotNil(t, s.GetSkipped()) {
+		assert.Equal(t, now.Add(-30*time.Minute), s.GetSkipped().Time)
+	}
+	assert.Equal(t, now, *s.GetLastRun())
+
+	// the run missed by 30 minutes is started inside a 1 hour window
+	assert.Nil(t, data.Settings.Set(&core.Settings{CatchUp: time.Hour}))
+	assert.Equal(t, core.InvalidSettings, data.Settings.Set(&core.Settings{CatchUp: -time.Hour}))
+	s.LastRun = &last
+	data.Schedules.CatchUp(now)
+	time.Sleep(100 * time.Millisecond)
+	assert.True(t, p.IsRunning())
+	assert.True(t, d1.IsOn())
+	assert.Equal(t, now.Add(-30*time.Minute), *s.GetLastRun())
+
+	// the run is not missed again
+	data.Programs.StopAll()
+	data.Schedules.CatchUp(now)
+	assert.False(t, p.IsRunning())
+
+	// only the runs inside the window are started
+	s.LastRun = &last
+	data.Schedules.CatchUp(now.Add(2 * time.Hour))
+	assert.False(t, p.IsRunning())
+	core.NewData()
+}
+
+func TestScheduleSkipPause(t *testing.T) {
+	gpioStub := NewGpioStub()
+	core.InitGpio(gpioStub)
+	data := core.NewData()
+	d1 := &core.Device{Name: "dev1", Pin: 1}
+	assert.Nil(t, data.Devices.Add(d1))
+	p := &core.Program{Name: "pr1"}
+	assert.Nil(t, p.AddDevice(d1, time.Second))
+	assert.Nil(t, data.Programs.Add(p))
+
+	s1 := &core.Schedule{Name: "sc1", Spec: "* * * * *", Program: p}
+	assert.Nil(t, data.Schedules.Add(s1))
+	s1.Sched = soonSchedule{100 * time.Millisecond}
+
+	// the next two runs are skipped
+	assert.Equal(t, core.InvalidSkipCount, s1.SkipRuns(-1))
+	assert.Nil(t, s1.SkipRuns(2))
+	s1.Enable()
+	time.Sleep(150 * time.Millisecond)
+	assert.False(t, p.IsRunning())
+	assert.Equal(t, 1, s1.GetSkipNext())
+	time.Sleep(100 * time.Millisecond)
+	assert.False(t, p.IsRunning())
+	assert.Equal(t, 0, s1.GetSkipNext())
+	if assert.NotNil(t, s1.GetSkipped()) {
+		assert.Contains(t, s1.GetSkipped().Reason, "skipped on request")
+	}
+	time.Sleep(100 * time.Millisecond)
+	s1.Disable()
+	assert.True(t, p.IsRunning())
+	p.Stop()
+
+	// the runs are skipped until the end of the pause
+	s1.Pause(time.Now().Add(250 * time.Millisecond))
+	_, paused := s1.GetPaused(time.Now())
+	assert.True(t, paused)
+	s1.Enable()
+	time.Sleep(150 * time.Millisecond)
+	assert.False(t, p.IsRunning())
+	assert.Contains(t, s1.GetSkipped().Reason, "paused until")
+	time.Sleep(250 * time.Millisecond)
+	s1.Disable()
+	assert.True(t, p.IsRunning())
+	p.Stop()
+
+	s1.Pause(time.Now().Add(time.Hour))
+	s1.Unpause()
+	_, paused = s1.GetPaused(time.Now())
+	assert.False(t, paused)
+	core.NewData()
+}
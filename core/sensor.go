@@ -0,0 +1,305 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/peter-vaczi/sprinkler/gpio"
+)
+
+var (
+	InvalidSensor = errors.New("Invalid sensor")
+)
+
+// sensor types, an active rain sensor blocks the schedules from starting
+// programs, a flow sensor counts the pulses of a flow meter
+const (
+	SensorRain = "rain"
+	SensorFlow = "flow"
+)
+
+// pull resistor configurations of the sensor pins
+const (
+	PullUp   = "up"
+	PullDown = "down"
+)
+
+// sensorPoll is the interval the sensor inputs are read
+var sensorPoll = 50 * time.Millisecond
+
+// flowPoll is the interval the flow sensor inputs are read, it has to be
+// shorter than the half period of the pulses at the highest flow
+var flowPoll = time.Millisecond
+
+// Sensor is a switch wired to a gpio input pin, the sensor is active when
+// its input is high (or low with ActiveLow) for at least Debounce time, a
+// flow sensor counts the pulses of its input, KFactor is the number of
+// pulses per liter
+type Sensor struct {
+	Name      string        `json:"name"`
+	Type      string        `json:"type"`
+	Pin       int           `json:"pin"`
+	Pull      string        `json:"pull,omitempty"`
+	ActiveLow bool          `json:"active-low,omitempty"`
+	Debounce  time.Duration `json:"debounce,omitempty"`
+	Abort     bool          `json:"abort,omitempty"`
+	KFactor   float64       `json:"k-factor,omitempty"`
+	Active    bool          `json:"active"`
+	Pulses    uint64        `json:"pulses,omitempty"`
+	pin       gpio.Pin
+	cancel    context.CancelFunc
+	m         sync.Mutex
+}
+
+type Sensors map[string]*Sensor
+
+func NewSensors() *Sensors {
+	s := make(Sensors)
+	return &s
+}
+
+func (s *Sensors) Add(sensor *Sensor) error {
+	mapsM.Lock()
+	defer mapsM.Unlock()
+
+	if _, exists := (*s)[sensor.Name]; exists {
+		return AlreadyExists
+	}
+	if !sensor.valid() {
+		return InvalidSensor
+	}
+
+	(*s)[sensor.Name] = sensor
+	sensor.Init()
+
+	return nil
+}
+
+func (s *Sensors) Get(name string) (*Sensor, error) {
+	mapsM.RLock()
+	defer mapsM.RUnlock()
+
+	if sensor, exists := (*s)[name]; exists {
+		return sensor, nil
+	}
+
+	return nil, NotFound
+}
+
+func (s *Sensors) Del(name string) error {
+	mapsM.Lock()
+	defer mapsM.Unlock()
+
+	if sensor, exists := (*s)[name]; exists {
+		sensor.stop()
+		delete(*s, name)
+		return nil
+	}
+
+	return NotFound
+}
+
+// List returns the sensors in no particular order
+func (s *Sensors) List() []*Sensor {
+	mapsM.RLock()
+	defer mapsM.RUnlock()
+
+	sensors := make([]*Sensor, 0, len(*s))
+	for _, sensor := range *s {
+		sensors = append(sensors, sensor)
+	}
+	return sensors
+}
+
+// MarshalJSON encodes the sensors while holding the lock of the maps
+func (s *Sensors) MarshalJSON() ([]byte, error) {
+	type sensors Sensors
+
+	mapsM.RLock()
+	defer mapsM.RUnlock()
+
+	return json.Marshal((*sensors)(s))
+}
+
+func (s *Sensors) StopAll() {
+	for _, sensor := range s.List() {
+		sensor.stop()
+	}
+}
+
+// blocking returns the name of an active rain sensor
+func (s *Sensors) blocking() (string, bool) {
+	for _, sensor := range s.List() {
+		if sensor.Type == SensorRain && sensor.IsActive() {
+			return sensor.Name, true
+		}
+	}
+	return "", false
+}
+
+// metered returns true if any of the sensors is a flow sensor
+func (s *Sensors) metered() bool {
+	for _, sensor := range s.List() {
+		if sensor.Type == SensorFlow {
+			return true
+		}
+	}
+	return false
+}
+
+// Init configures the input pin of the sensor and starts reading it
+func (s *Sensor) Init() {
+	s.stop()
+
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	s.pin = gpioLib.NewPin(s.Pin)
+	s.pin.Input()
+	switch s.Pull {
+	case PullUp:
+		s.pin.Pull(gpio.PullUp)
+	case PullDown:
+		s.pin.Pull(gpio.PullDown)
+	default:
+		s.pin.Pull(gpio.PullOff)
+	}
+	s.Active = s.read()
+
+	var ctx context.Context
+	ctx, s.cancel = context.WithCancel(context.Background())
+	if s.Type == SensorFlow {
+		go s.count(ctx)
+	} else {
+		go s.poll(ctx)
+	}
+}
+
+// MarshalJSON encodes the sensor while holding its lock, the sensor is
+// updated by its reader at any time
+func (s *Sensor) MarshalJSON() ([]byte, error) {
+	type sensor Sensor
+
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	return json.Marshal((*sensor)(s))
+}
+
+func (s *Sensor) IsActive() bool {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	return s.Active
+}
+
+func (s *Sensor) valid() bool {
+	switch s.Pull {
+	case "", PullUp, PullDown:
+	default:
+		return false
+	}
+	switch s.Type {
+	case SensorRain:
+		return s.Debounce >= 0
+	case SensorFlow:
+		return s.KFactor > 0
+	}
+	return false
+}
+
+func (s *Sensor) stop() {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	if s.cancel != nil {
+		s.cancel()
+		s.cancel = nil
+	}
+}
+
+// read returns the raw state of the input
+func (s *Sensor) read() bool {
+	return s.pin.Read() != s.ActiveLow
+}
+
+// poll reads the input periodically, the state of the sensor is changed if
+// the input keeps its new state for the debounce time
+func (s *Sensor) poll(ctx context.Context) {
+	var changed time.Time
+
+	t := time.NewTicker(sensorPoll)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-t.C:
+			s.m.Lock()
+			active := s.read()
+			if active == s.Active {
+				changed = time.Time{}
+				s.m.Unlock()
+				continue
+			}
+			if changed.IsZero() {
+				changed = now
+			}
+			if now.Sub(changed) < s.Debounce {
+				s.m.Unlock()
+				continue
+			}
+			s.Active = active
+			changed = time.Time{}
+			s.m.Unlock()
+
+			s.changed(active)
+		}
+	}
+}
+
+// count reads the input of a flow sensor periodically, every rising edge
+// is a pulse accounted to the open zones
+func (s *Sensor) count(ctx context.Context) {
+	t := time.NewTicker(flowPoll)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			s.m.Lock()
+			active := s.read()
+			pulse := active && !s.Active
+			s.Active = active
+			if pulse {
+				s.Pulses++
+			}
+			kFactor := s.KFactor
+			s.m.Unlock()
+
+			if pulse {
+				ctrl.flow(1 / kFactor)
+			}
+		}
+	}
+}
+
+func (s *Sensor) changed(active bool) {
+	if !active {
+		log.Printf("sensor %s is inactive", s.Name)
+		return
+	}
+
+	log.Printf("sensor %s is active", s.Name)
+	if s.Type == SensorRain && s.Abort {
+		log.Printf("sensor %s aborts the running programs", s.Name)
+		ctrl.stopPrograms()
+	}
+}
@@ -0,0 +1,71 @@
+package core_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/peter-vaczi/sprinkler/core"
+	"github.com/peter-vaczi/sprinkler/gpio"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSensors(t *testing.T) {
+	gpioStub := NewGpioStub()
+	core.InitGpio(gpioStub)
+	sensors := core.NewSensors()
+
+	assert.Equal(t, core.InvalidSensor, sensors.Add(&core.Sensor{Name: "s1", Type: "unknown"}))
+	assert.Equal(t, core.InvalidSensor, sensors.Add(&core.Sensor{Name: "s1", Type: core.SensorRain, Pull: "sideways"}))
+
+	s1 := &core.Sensor{Name: "s1", Type: core.SensorRain, Pin: 5, Pull: core.PullUp, ActiveLow: true, Debounce: 200 * time.Millisecond}
+	assert.Nil(t, sensors.Add(s1))
+	assert.Equal(t, core.AlreadyExists, sensors.Add(s1))
+
+	p := gpioStub.pins[5]
+	assert.NotNil(t, p)
+	assert.False(t, p.output)
+	assert.Equal(t, gpio.PullUp, p.pull)
+	assert.True(t, s1.IsActive())
+
+	// a short glitch does not change the state
+	p.setHigh(true)
+	time.Sleep(100 * time.Millisecond)
+	p.setHigh(false)
+	time.Sleep(200 * time.Millisecond)
+	assert.True(t, s1.IsActive())
+
+	p.setHigh(true)
+	time.Sleep(400 * time.Millisecond)
+	assert.False(t, s1.IsActive())
+
+	s, err := sensors.Get("s1")
+	assert.Equal(t, s1, s)
+	assert.Nil(t, err)
+
+	assert.Equal(t, core.NotFound, sensors.Del("s"))
+	assert.Nil(t, sensors.Del("s1"))
+	assert.Empty(t, *sensors)
+}
+
+func TestSensorAbort(t *testing.T) {
+	gpioStub := NewGpioStub()
+	core.InitGpio(gpioStub)
+	data := core.NewData()
+	d1 := &core.Device{Name: "dev1", Pin: 1}
+	assert.Nil(t, data.Devices.Add(d1))
+	p := &core.Program{Name: "pr1"}
+	assert.Nil(t, p.AddDevice(d1, 10*time.Second))
+	assert.Nil(t, data.Programs.Add(p))
+	assert.Nil(t, data.Sensors.Add(&core.Sensor{Name: "rain", Type: core.SensorRain, Pin: 5, Abort: true}))
+
+	p.Start()
+	time.Sleep(100 * time.Millisecond)
+	assert.True(t, d1.IsOn())
+
+	gpioStub.pins[5].setHigh(true)
+	time.Sleep(200 * time.Millisecond)
+	assert.False(t, d1.IsOn())
+
+	data.Sensors.StopAll()
+	core.NewData()
+}
@@ -0,0 +1,326 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"sync"
+	"time"
+)
+
+var (
+	InvalidSettings = errors.New("Invalid settings")
+)
+
+// actions taken when the flow of a program step is out of the tolerance
+const (
+	FlowSkip = "skip"
+	FlowStop = "stop"
+)
+
+// defaults of the flow monitoring settings
+const (
+	defaultFlowTolerance = 50
+	defaultFlowWindow    = time.Minute
+)
+
+// Settings holds the controller wide configuration
+type Settings struct {
+	MaxOpenValves  int           `json:"max-open-valves,omitempty"`
+	Adjustment     Adjustment    `json:"adjustment,omitempty"`
+	RainDelay      *time.Time    `json:"rain-delay,omitempty"`
+	FlowTolerance  int           `json:"flow-tolerance,omitempty"`
+	FlowAction     string        `json:"flow-action,omitempty"`
+	FlowWindow     time.Duration `json:"flow-window,omitempty"`
+	LeakFlow       float64       `json:"leak-flow,omitempty"`
+	Latitude       float64       `json:"latitude,omitempty"`
+	Longitude      float64       `json:"longitude,omitempty"`
+	Blackouts      []*Blackout   `json:"blackouts,omitempty"`
+	BlackoutPolicy string        `json:"blackout-policy,omitempty"`
+	OverlapPolicy  string        `json:"overlap-policy,omitempty"`
+	Serialize      bool          `json:"serialize,omitempty"`
+	CatchUp        time.Duration `json:"catch-up,omitempty"`
+	Resume         bool          `json:"resume,omitempty"`
+	m              sync.Mutex
+}
+
+// FlowMonitor is the configuration of the flow monitoring: the measured flow
+// of a step may differ from the expected one by Tolerance percent, Action is
+// taken otherwise, the flow is measured over Window, a flow above LeakFlow
+// liters per minute while every zone is closed is a leak
+type FlowMonitor struct {
+	Tolerance int
+	Action    string
+	Window    time.Duration
+	LeakFlow  float64
+}
+
+func NewSettings() *Settings {
+	return &Settings{}
+}
+
+// Set overwrites the settings with the values of newSet
+func (s *Settings) Set(newSet *Settings) error {
+	if newSet.MaxOpenValves < 0 || newSet.FlowTolerance < 0 || newSet.FlowWindow < 0 || newSet.LeakFlow < 0 || newSet.CatchUp < 0 {
+		return InvalidSettings
+	}
+	if math.Abs(newSet.Latitude) > 90 || math.Abs(newSet.Longitude) > 180 {
+		return InvalidSettings
+	}
+	switch newSet.BlackoutPolicy {
+	case "", BlackoutSkip, BlackoutShift:
+	default:
+		return InvalidSettings
+	}
+	if err := validateBlackouts(newSet.Blackouts); err != nil {
+		return err
+	}
+	switch newSet.OverlapPolicy {
+	case "", OverlapSkip, OverlapQueue, OverlapPreempt:
+	default:
+		return InvalidSettings
+	}
+	switch newSet.FlowAction {
+	case "", FlowSkip, FlowStop:
+	default:
+		return InvalidSettings
+	}
+	if err := newSet.Adjustment.validate(); err != nil {
+		return err
+	}
+
+	s.m.Lock()
+	s.MaxOpenValves = newSet.MaxOpenValves
+	s.Adjustment = newSet.Adjustment.copy()
+	s.RainDelay = newSet.RainDelay
+	s.FlowTolerance = newSet.FlowTolerance
+	s.FlowAction = newSet.FlowAction
+	s.FlowWindow = newSet.FlowWindow
+	s.LeakFlow = newSet.LeakFlow
+	s.Latitude = newSet.Latitude
+	s.Longitude = newSet.Longitude
+	s.Blackouts = copyBlackouts(newSet.Blackouts)
+	s.BlackoutPolicy = newSet.BlackoutPolicy
+	s.OverlapPolicy = newSet.OverlapPolicy
+	s.Serialize = newSet.Serialize
+	s.CatchUp = newSet.CatchUp
+	s.Resume = newSet.Resume
+	s.m.Unlock()
+
+	// a higher limit may let waiting programs continue
+	ctrl.notify()
+	// the sun schedules have to be recomputed at the new location, the
+	// shifted runs at the new blackouts
+	ctrl.rearmSchedules()
+	// the queued programs may be allowed to run without serialization
+	ctrl.programDone()
+	return nil
+}
+
+// SetBlackouts sets the blackout windows of the schedules
+func (s *Settings) SetBlackouts(blackouts []*Blackout) error {
+	if err := validateBlackouts(blackouts); err != nil {
+		return err
+	}
+
+	s.m.Lock()
+	s.Blackouts = copyBlackouts(blackouts)
+	s.m.Unlock()
+
+	ctrl.rearmSchedules()
+	return nil
+}
+
+func (s *Settings) GetBlackouts() []*Blackout {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	return copyBlackouts(s.Blackouts)
+}
+
+// GetBlackoutPolicy returns the policy of the schedules starting inside a
+// blackout window, skip by default
+func (s *Settings) GetBlackoutPolicy() string {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	if s.BlackoutPolicy == "" {
+		return BlackoutSkip
+	}
+	return s.BlackoutPolicy
+}
+
+// GetOverlapPolicy returns the policy of the programs started while they are
+// running, skip by default, serialize is true if only one program may run at
+// a time
+func (s *Settings) GetOverlapPolicy() (policy string, serialize bool) {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	if s.OverlapPolicy == "" {
+		return OverlapSkip, s.Serialize
+	}
+	return s.OverlapPolicy, s.Serialize
+}
+
+// GetCatchUp returns how late the runs missed while the daemon was down may
+// still be started, 0 means they are skipped
+func (s *Settings) GetCatchUp() time.Duration {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	return s.CatchUp
+}
+
+// GetResume returns true if the programs interrupted by the shutdown of the
+// daemon are resumed when it starts again
+func (s *Settings) GetResume() bool {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	return s.Resume
+}
+
+// GetBlackout returns the blackout window the time t is inside of and the
+// end of it, the end of the adjacent windows is returned if they overlap
+func (s *Settings) GetBlackout(t time.Time) (string, time.Time, bool) {
+	blackouts := s.GetBlackouts()
+
+	var name string
+	var end time.Time
+	for found := true; found; {
+		found = false
+		for _, b := range blackouts {
+			if e, inside := b.end(t); inside && e.After(end) {
+				if name == "" {
+					name = b.Name
+				}
+				end = e
+				t = e
+				found = true
+			}
+		}
+	}
+	return name, end, name != ""
+}
+
+func validateBlackouts(blackouts []*Blackout) error {
+	names := make(map[string]bool)
+	for _, b := range blackouts {
+		if err := b.validate(); err != nil {
+			return err
+		}
+		if names[b.Name] {
+			return InvalidBlackout
+		}
+		names[b.Name] = true
+	}
+	return nil
+}
+
+func copyBlackouts(blackouts []*Blackout) []*Blackout {
+	var copied []*Blackout
+	for _, b := range blackouts {
+		c := *b
+		c.Days = append([]string(nil), b.Days...)
+		copied = append(copied, &c)
+	}
+	return copied
+}
+
+// GetLocation returns the latitude and longitude of the controller, found is
+// false if they are not set
+func (s *Settings) GetLocation() (lat, lon float64, found bool) {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	return s.Latitude, s.Longitude, s.Latitude != 0 || s.Longitude != 0
+}
+
+// GetMaxOpenValves returns the number of valves allowed to be open at the
+// same time, 0 means no limit
+func (s *Settings) GetMaxOpenValves() int {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	return s.MaxOpenValves
+}
+
+// SetAdjustment sets the controller wide seasonal adjustment table
+func (s *Settings) SetAdjustment(adj Adjustment) error {
+	if err := adj.validate(); err != nil {
+		return err
+	}
+
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	s.Adjustment = adj.copy()
+	return nil
+}
+
+// GetAdjustment returns a copy of the controller wide seasonal adjustment table
+func (s *Settings) GetAdjustment() Adjustment {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	return s.Adjustment.copy()
+}
+
+// SetRainDelay suspends every schedule until the given time, the delay is
+// saved right away
+func (s *Settings) SetRainDelay(until time.Time) {
+	s.m.Lock()
+	s.RainDelay = &until
+	s.m.Unlock()
+
+	ctrl.storeState()
+}
+
+func (s *Settings) ClearRainDelay() {
+	s.m.Lock()
+	s.RainDelay = nil
+	s.m.Unlock()
+
+	ctrl.storeState()
+}
+
+// GetRainDelay returns the end of the rain delay if it is active at the time t
+func (s *Settings) GetRainDelay(t time.Time) (time.Time, bool) {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	if s.RainDelay == nil || !t.Before(*s.RainDelay) {
+		return time.Time{}, false
+	}
+	return *s.RainDelay, true
+}
+
+// GetFlowMonitor returns the flow monitoring settings with the defaults
+// filled in
+func (s *Settings) GetFlowMonitor() FlowMonitor {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	mon := FlowMonitor{Tolerance: s.FlowTolerance, Action: s.FlowAction, Window: s.FlowWindow, LeakFlow: s.LeakFlow}
+	if mon.Tolerance == 0 {
+		mon.Tolerance = defaultFlowTolerance
+	}
+	if mon.Action == "" {
+		mon.Action = FlowSkip
+	}
+	if mon.Window == 0 {
+		mon.Window = defaultFlowWindow
+	}
+	return mon
+}
+
+// MarshalJSON encodes the settings while holding their lock
+func (s *Settings) MarshalJSON() ([]byte, error) {
+	type settings Settings
+
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	return json.Marshal((*settings)(s))
+}
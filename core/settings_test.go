@@ -0,0 +1,30 @@
+package core_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/peter-vaczi/sprinkler/core"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSettingsRainDelay(t *testing.T) {
+	set := core.NewSettings()
+	now := time.Now()
+
+	_, delayed := set.GetRainDelay(now)
+	assert.False(t, delayed)
+
+	set.SetRainDelay(now.Add(48 * time.Hour))
+	until, delayed := set.GetRainDelay(now)
+	assert.True(t, delayed)
+	assert.Equal(t, now.Add(48*time.Hour), until)
+
+	// the delay expires on its own
+	_, delayed = set.GetRainDelay(now.Add(49 * time.Hour))
+	assert.False(t, delayed)
+
+	set.ClearRainDelay()
+	_, delayed = set.GetRainDelay(now)
+	assert.False(t, delayed)
+}
@@ -0,0 +1,72 @@
+package core
+
+import "time"
+
+// states of the programs
+const (
+	StateIdle    = "idle"
+	StateRunning = "running"
+	StatePaused  = "paused"
+)
+
+// ProgramStatus is the live state of a program: the step running, the
+// element and the devices of it if they are open, the elapsed and remaining
+// time of the step and of the whole run, when and by whom the run was started
+type ProgramStatus struct {
+	State          string        `json:"state"`
+	Step           *int          `json:"step,omitempty"`
+	Element        *int          `json:"element,omitempty"`
+	Devices        []string      `json:"devices,omitempty"`
+	Elapsed        time.Duration `json:"elapsed,omitempty"`
+	Remaining      time.Duration `json:"remaining,omitempty"`
+	TotalRemaining time.Duration `json:"total-remaining,omitempty"`
+	Started        *time.Time    `json:"started,omitempty"`
+	Source         string        `json:"source,omitempty"`
+}
+
+// Status returns the live state of the program
+func (p *Program) Status() *ProgramStatus {
+	p.m.Lock()
+	defer p.m.Unlock()
+
+	if p.Paused {
+		status := &ProgramStatus{State: StatePaused}
+		if p.Progress != nil {
+			step := p.Progress.Step
+			status.Step = &step
+			status.Remaining = p.Progress.Remaining
+		}
+		return status
+	}
+	if !p.running {
+		return &ProgramStatus{State: StateIdle}
+	}
+
+	started := p.started
+	status := &ProgramStatus{State: StateRunning, Started: &started, Source: p.source}
+	if p.step >= len(p.steps) {
+		return status
+	}
+
+	step := p.steps[p.step]
+	idx, elem := p.step, step.Element
+	status.Step = &idx
+	status.Element = &elem
+	status.Remaining = step.Duration
+	if !p.stepStart.IsZero() {
+		status.Devices = step.Devices
+		status.Elapsed = time.Since(p.stepStart)
+		status.Remaining = p.stepLength - status.Elapsed
+		if status.Remaining < 0 {
+			status.Remaining = 0
+		}
+	}
+
+	// the rest of the run is estimated from the timeline
+	last := p.steps[len(p.steps)-1]
+	status.TotalRemaining = status.Remaining + last.end() - step.end()
+	if status.TotalRemaining < status.Remaining {
+		status.TotalRemaining = status.Remaining
+	}
+	return status
+}
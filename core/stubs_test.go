@@ -1,6 +1,11 @@
 package core_test
 
-import "github.com/peter-vaczi/sprinkler/gpio"
+import (
+	"sync"
+	"time"
+
+	"github.com/peter-vaczi/sprinkler/gpio"
+)
 
 type GpioStub struct {
 	pins map[int]*PinStub
@@ -20,8 +25,46 @@ type PinStub struct {
 	pin    int
 	output bool
 	high   bool
+	pull   gpio.Pull
+	m      sync.Mutex
+}
+
+func (p *PinStub) Output()             { p.output = true }
+func (p *PinStub) High()               { p.setHigh(true) }
+func (p *PinStub) Low()                { p.setHigh(false) }
+func (p *PinStub) Input()              { p.output = false }
+func (p *PinStub) Pull(pull gpio.Pull) { p.pull = pull }
+
+func (p *PinStub) Read() bool {
+	p.m.Lock()
+	defer p.m.Unlock()
+
+	return p.high
 }
 
-func (p *PinStub) Output() { p.output = true }
-func (p *PinStub) High()   { p.high = true }
-func (p *PinStub) Low()    { p.high = false }
+func (p *PinStub) setHigh(high bool) {
+	p.m.Lock()
+	defer p.m.Unlock()
+
+	p.high = high
+}
+
+// pulse generates 50 pulses per second on the pin while flowing returns
+// true, it is 300 l/min with a k-factor of 10, the returned function stops it
+func (p *PinStub) pulse(flowing func() bool) func() {
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			p.setHigh(flowing())
+			time.Sleep(10 * time.Millisecond)
+			p.setHigh(false)
+			time.Sleep(10 * time.Millisecond)
+		}
+	}()
+	return func() { close(done) }
+}
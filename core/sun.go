@@ -0,0 +1,110 @@
+package core
+
+import (
+	"math"
+	"strings"
+	"time"
+)
+
+// sun events of the sun schedules
+const (
+	Sunrise = "@sunrise"
+	Sunset  = "@sunset"
+)
+
+// sunSchedule starts the program at sunrise or sunset shifted by offset, with
+// finish it is started early enough to finish at that time
+type sunSchedule struct {
+	event    string
+	offset   time.Duration
+	finish   bool
+	duration func() time.Duration
+}
+
+// parseSunSpec parses the spec of a sun schedule:
+// @sunrise|@sunset [offset] [finish], e.g. "@sunrise -30m finish",
+// duration returns the run time of the program for the finish semantics
+func parseSunSpec(spec string, duration func() time.Duration) (*sunSchedule, error) {
+	fields := strings.Fields(spec)
+	if len(fields) == 0 || (fields[0] != Sunrise && fields[0] != Sunset) {
+		return nil, InvalidSpec
+	}
+
+	sc := &sunSchedule{event: fields[0], duration: duration}
+	for _, field := range fields[1:] {
+		if field == "finish" {
+			sc.finish = true
+			continue
+		}
+		offset, err := time.ParseDuration(field)
+		if err != nil {
+			return nil, InvalidSpec
+		}
+		sc.offset = offset
+	}
+	return sc, nil
+}
+
+// Next returns the first start after t, or the zero time if the location
+// of the controller is not set or the sun does not rise or set for a year
+func (s *sunSchedule) Next(t time.Time) time.Time {
+	lat, lon, found := ctrl.location()
+	if !found {
+		return time.Time{}
+	}
+
+	var shift time.Duration
+	if s.finish && s.duration != nil {
+		shift = s.duration()
+	}
+
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	for i := -1; i < 367; i++ {
+		rise, set, ok := sunTimes(day.AddDate(0, 0, i), lat, lon)
+		if !ok {
+			continue
+		}
+		event := rise
+		if s.event == Sunset {
+			event = set
+		}
+		next := event.Add(s.offset - shift).In(t.Location())
+		if next.After(t) {
+			return next
+		}
+	}
+	return time.Time{}
+}
+
+// sunTimes computes the sunrise and sunset on the given day at the latitude
+// and longitude (east positive) in degrees with the sunrise equation, it is
+// accurate to a minute or two, ok is false on polar days and nights
+func sunTimes(day time.Time, lat, lon float64) (rise, set time.Time, ok bool) {
+	rad := math.Pi / 180
+
+	noon := time.Date(day.Year(), day.Month(), day.Day(), 12, 0, 0, 0, time.UTC)
+	n := math.Floor(julian(noon) - 2451545.0 + 0.0008)
+	mean := n - lon/360
+
+	anomaly := math.Mod(357.5291+0.98560028*mean, 360)
+	center := 1.9148*math.Sin(anomaly*rad) + 0.02*math.Sin(2*anomaly*rad) + 0.0003*math.Sin(3*anomaly*rad)
+	longitude := math.Mod(anomaly+center+180+102.9372, 360)
+	transit := 2451545.0 + mean + 0.0053*math.Sin(anomaly*rad) - 0.0069*math.Sin(2*longitude*rad)
+
+	declination := math.Asin(math.Sin(longitude*rad) * math.Sin(23.44*rad))
+	cosHour := (math.Sin(-0.833*rad) - math.Sin(lat*rad)*math.Sin(declination)) / (math.Cos(lat*rad) * math.Cos(declination))
+	if cosHour < -1 || cosHour > 1 {
+		return time.Time{}, time.Time{}, false
+	}
+	hour := math.Acos(cosHour) / rad
+
+	return fromJulian(transit - hour/360), fromJulian(transit + hour/360), true
+}
+
+func julian(t time.Time) float64 {
+	return float64(t.Unix())/86400 + 2440587.5
+}
+
+func fromJulian(j float64) time.Time {
+	return time.Unix(int64(math.Round((j-2440587.5)*86400)), 0)
+}
@@ -0,0 +1,60 @@
+package core_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/peter-vaczi/sprinkler/core"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSunSchedules(t *testing.T) {
+	gpioStub := NewGpioStub()
+	core.InitGpio(gpioStub)
+	data := core.NewData()
+	d1 := &core.Device{Name: "dev1", Pin: 1}
+	d2 := &core.Device{Name: "dev2", Pin: 2}
+	assert.Nil(t, data.Devices.Add(d1))
+	assert.Nil(t, data.Devices.Add(d2))
+	p := &core.Program{Name: "pr1"}
+	assert.Nil(t, p.AddDevice(d1, 20*time.Minute))
+	assert.Nil(t, p.AddDevice(d2, 10*time.Minute))
+	assert.Nil(t, data.Programs.Add(p))
+
+	s1 := &core.Schedule{Name: "sc1", Spec: "@sunrise", Program: p}
+	s2 := &core.Schedule{Name: "sc2", Spec: "@sunset +1h", Program: p}
+	s3 := &core.Schedule{Name: "sc3", Spec: "@sunrise -30m finish", Program: p}
+	assert.Equal(t, core.InvalidSpec, data.Schedules.Add(&core.Schedule{Name: "sc4", Spec: "@sunrise soon"}))
+	assert.Nil(t, data.Schedules.Add(s1))
+	assert.Nil(t, data.Schedules.Add(s2))
+	assert.Nil(t, data.Schedules.Add(s3))
+
+	// there is no sunrise without a location
+	assert.True(t, s1.GetNext().IsZero())
+
+	// Budapest: sunrise at 04:46, sunset at 20:45 CEST on the longest day
+	assert.Equal(t, core.InvalidSettings, data.Settings.Set(&core.Settings{Latitude: 91}))
+	assert.Nil(t, data.Settings.Set(&core.Settings{Latitude: 47.4979, Longitude: 19.0402}))
+	now := time.Date(2026, 6, 20, 12, 0, 0, 0, time.UTC)
+	sunrise := time.Date(2026, 6, 21, 2, 46, 0, 0, time.UTC)
+	sunset := time.Date(2026, 6, 20, 18, 45, 0, 0, time.UTC)
+
+	assert.WithinDuration(t, sunrise, s1.Sched.Next(now), 3*time.Minute)
+	assert.WithinDuration(t, sunset.Add(time.Hour), s2.Sched.Next(now), 3*time.Minute)
+	assert.WithinDuration(t, sunset.Add(time.Hour+24*time.Hour), s2.Sched.Next(sunset.Add(2*time.Hour)), 3*time.Minute)
+
+	// the program runs for 30m1s and finishes 30 minutes before sunrise
+	assert.Equal(t, 30*time.Minute+time.Second, p.Duration())
+	assert.WithinDuration(t, sunrise.Add(-time.Hour-time.Second), s3.Sched.Next(now), 3*time.Minute)
+
+	next := s1.GetNext()
+	assert.True(t, next.After(time.Now()))
+	assert.True(t, next.Before(time.Now().Add(25*time.Hour)))
+
+	// no sunrise in the polar night
+	assert.Nil(t, data.Settings.Set(&core.Settings{Latitude: 78.2, Longitude: 15.6}))
+	winter := time.Date(2026, 12, 21, 12, 0, 0, 0, time.UTC)
+	assert.True(t, s1.Sched.Next(winter).After(time.Date(2027, 2, 1, 0, 0, 0, 0, time.UTC)))
+
+	core.NewData()
+}
@@ -0,0 +1,211 @@
+package core
+
+import "time"
+
+// zoneDelay is the default pause between two steps of a program
+const zoneDelay = 1 * time.Second
+
+// volumeCeiling is the longest a volume based element may run if it has no
+// duration set
+const volumeCeiling = 1 * time.Hour
+
+// volumePoll is the interval the delivered volume of a step is checked
+var volumePoll = 100 * time.Millisecond
+
+// Step is a single valve opening of a program run, elements with a cycle
+// time are split into several steps, the duration of a volume based step is
+// estimated from the expected flow of its devices, the zones of a step open
+// at Start and the watering starts after they settled, an overlapping step
+// opens that long before the zones of the previous step close
+type Step struct {
+	Element  int           `json:"element"`
+	Cycle    int           `json:"cycle"`
+	Devices  []string      `json:"devices"`
+	Start    time.Duration `json:"start"`
+	Settle   time.Duration `json:"settle,omitempty"`
+	Overlap  time.Duration `json:"overlap,omitempty"`
+	Duration time.Duration `json:"duration"`
+	Volume   float64       `json:"volume,omitempty"`
+}
+
+// end returns the offset the zones of the step close at
+func (s *Step) end() time.Duration {
+	return s.Start + s.Settle + s.Duration
+}
+
+// Timeline returns the steps of the program with their start offsets
+// relative to the start of the program, the durations are scaled by the
+// seasonal adjustment of the current month
+func (p *Program) Timeline() []*Step {
+	return p.timelineAt(time.Now())
+}
+
+// timelineAt returns the steps of the program started at the time t
+func (p *Program) timelineAt(t time.Time) []*Step {
+	scale := p.Scale(t)
+
+	p.m.Lock()
+	elements := p.Elements
+	p.m.Unlock()
+	delay, overlap := p.delays()
+
+	return timeline(elements, scale, delay, overlap)
+}
+
+// Duration returns the run time of the program at the current month
+func (p *Program) Duration() time.Duration {
+	steps := p.Timeline()
+	if len(steps) == 0 {
+		return 0
+	}
+	return steps[len(steps)-1].end()
+}
+
+// cycles splits the scaled duration of the element into equal cycles not
+// longer than the cycle time of the element
+func (e *ProgramElement) cycles(scale int) []time.Duration {
+	if e.Volume > 0 {
+		return []time.Duration{e.estimate(scale)}
+	}
+
+	duration := scaleDuration(e.Duration, scale)
+	if duration <= 0 {
+		return nil
+	}
+	if e.Cycle <= 0 || duration <= e.Cycle {
+		return []time.Duration{duration}
+	}
+
+	n := int((duration + e.Cycle - 1) / e.Cycle)
+	cycles := make([]time.Duration, n)
+	for i := range cycles {
+		cycles[i] = duration / time.Duration(n)
+	}
+	cycles[n-1] += duration - cycles[0]*time.Duration(n)
+	return cycles
+}
+
+// volume returns the scaled volume of the element
+func (e *ProgramElement) volume(scale int) float64 {
+	return e.Volume * float64(scale) / 100
+}
+
+// ceiling returns the longest time a volume based element may run
+func (e *ProgramElement) ceiling() time.Duration {
+	if e.Duration > 0 {
+		return e.Duration
+	}
+	return volumeCeiling
+}
+
+// estimate returns the time needed to deliver the scaled volume of the
+// element at the expected flow of its devices, the ceiling if the flow of
+// any of them is not known
+func (e *ProgramElement) estimate(scale int) time.Duration {
+	var flow float64
+	for _, dev := range e.Zones() {
+		if dev.GetFlow() == 0 {
+			return e.ceiling()
+		}
+		flow += dev.GetFlow()
+	}
+
+	estimate := time.Duration(e.volume(scale) / flow * float64(time.Minute))
+	if estimate > e.ceiling() {
+		return e.ceiling()
+	}
+	return estimate
+}
+
+// settle returns the longest open and close settle times of the zones of
+// the element
+func (e *ProgramElement) settle() (time.Duration, time.Duration) {
+	var open, close time.Duration
+	for _, dev := range e.Zones() {
+		o, c := dev.GetSettle()
+		if o > open {
+			open = o
+		}
+		if c > close {
+			close = c
+		}
+	}
+	return open, close
+}
+
+// gap returns the pause after the element: the delay of the program, or the
+// close settle time of its zones if that is longer
+func (e *ProgramElement) gap(delay time.Duration) time.Duration {
+	_, close := e.settle()
+	if close > delay {
+		return close
+	}
+	return delay
+}
+
+// timeline orders the cycles of the elements: the first element which is
+// not soaking is started next, if all of them are soaking the one ready
+// first is started after waiting for it, the steps are separated by the
+// delay unless overlap is set, in which case a time based step opens that
+// long before the previous time based step closes if it is ready by then
+func timeline(elements []*ProgramElement, scale int, delay, overlap time.Duration) []*Step {
+	cycles := make([][]time.Duration, len(elements))
+	counts := make([]int, len(elements))
+	ready := make([]time.Duration, len(elements))
+	for i, elem := range elements {
+		cycles[i] = elem.cycles(scale)
+		counts[i] = len(cycles[i])
+	}
+
+	steps := []*Step{}
+	var now time.Duration
+	var prev *Step
+	for {
+		idx := -1
+		for i := range elements {
+			if len(cycles[i]) == 0 {
+				continue
+			}
+			if ready[i] <= now {
+				idx = i
+				break
+			}
+			if idx == -1 || ready[i] < ready[idx] {
+				idx = i
+			}
+		}
+		if idx == -1 {
+			return steps
+		}
+		if ready[idx] > now {
+			now = ready[idx]
+		}
+
+		elem := elements[idx]
+		step := &Step{
+			Element:  idx,
+			Cycle:    counts[idx] - len(cycles[idx]),
+			Devices:  append([]string{elem.DeviceName}, elem.ParallelNames...),
+			Start:    now,
+			Duration: cycles[idx][0],
+		}
+		step.Settle, _ = elem.settle()
+		if elem.Volume > 0 {
+			step.Volume = elem.volume(scale)
+		}
+		if overlap > 0 && prev != nil && prev.Element != idx && prev.Volume == 0 && step.Volume == 0 &&
+			prev.Duration > overlap && ready[idx] <= prev.end()-overlap {
+			step.Start = prev.end() - overlap
+			step.Overlap = overlap
+			if step.Settle < overlap {
+				step.Settle = overlap
+			}
+		}
+		steps = append(steps, step)
+		cycles[idx] = cycles[idx][1:]
+
+		ready[idx] = step.end() + elem.Soak
+		now = step.end() + elem.gap(delay)
+		prev = step
+	}
+}
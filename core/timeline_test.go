@@ -0,0 +1,151 @@
+package core_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/peter-vaczi/sprinkler/core"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTimeline(t *testing.T) {
+	d1 := &core.Device{Name: "dev1"}
+	d2 := &core.Device{Name: "dev2"}
+	d3 := &core.Device{Name: "dev3"}
+	p := &core.Program{Name: "pr1"}
+
+	assert.Empty(t, p.Timeline())
+
+	assert.Equal(t, core.InvalidDuration, p.AddElement(&core.ProgramElement{Device: d1, Duration: time.Minute, Cycle: -1}))
+	assert.Nil(t, p.AddElement(&core.ProgramElement{Device: d1, Duration: 15 * time.Minute, Cycle: 6 * time.Minute, Soak: 10 * time.Minute}))
+	assert.Nil(t, p.AddDevice(d2, 4*time.Minute, d3))
+
+	steps := p.Timeline()
+	if assert.Equal(t, 4, len(steps)) {
+		// first cycle of dev1, dev2 runs while dev1 soaks
+		assert.Equal(t, 0, steps[0].Element)
+		assert.Equal(t, 0, steps[0].Cycle)
+		assert.Equal(t, time.Duration(0), steps[0].Start)
+		assert.Equal(t, 5*time.Minute, steps[0].Duration)
+
+		assert.Equal(t, 1, steps[1].Element)
+		assert.Equal(t, []string{"dev2", "dev3"}, steps[1].Devices)
+		assert.Equal(t, 5*time.Minute+time.Second, steps[1].Start)
+
+		// nothing else to do, wait for the soak of dev1
+		assert.Equal(t, 0, steps[2].Element)
+		assert.Equal(t, 1, steps[2].Cycle)
+		assert.Equal(t, 15*time.Minute, steps[2].Start)
+
+		assert.Equal(t, 0, steps[3].Element)
+		assert.Equal(t, 2, steps[3].Cycle)
+		assert.Equal(t, 30*time.Minute, steps[3].Start)
+		assert.Equal(t, 5*time.Minute, steps[3].Duration)
+	}
+}
+
+func TestTimelineDelay(t *testing.T) {
+	d1 := &core.Device{Name: "dev1", CloseSettle: 5 * time.Second}
+	d2 := &core.Device{Name: "dev2", OpenSettle: 2 * time.Second}
+	d3 := &core.Device{Name: "dev3"}
+	p := &core.Program{Name: "pr1"}
+	assert.Nil(t, p.AddDevice(d1, time.Minute))
+	assert.Nil(t, p.AddDevice(d2, time.Minute))
+	assert.Nil(t, p.AddDevice(d3, time.Minute))
+
+	// the close settle time of dev1 is longer than the default delay
+	steps := p.Timeline()
+	if assert.Equal(t, 3, len(steps)) {
+		assert.Equal(t, time.Minute+5*time.Second, steps[1].Start)
+		assert.Equal(t, 2*time.Second, steps[1].Settle)
+		assert.Equal(t, 2*time.Minute+8*time.Second, steps[2].Start)
+	}
+
+	delay := -time.Second
+	assert.Equal(t, core.InvalidDuration, p.SetDelay(&delay, 0))
+	assert.Equal(t, core.InvalidDuration, p.SetDelay(nil, -1))
+	delay = 10 * time.Second
+	assert.Nil(t, p.SetDelay(&delay, 0))
+	steps = p.Timeline()
+	if assert.Equal(t, 3, len(steps)) {
+		assert.Equal(t, time.Minute+10*time.Second, steps[1].Start)
+		assert.Equal(t, 2*time.Minute+22*time.Second, steps[2].Start)
+	}
+
+	// no pause between the steps, only the close settle time of dev1
+	delay = 0
+	assert.Nil(t, p.SetDelay(&delay, 0))
+	steps = p.Timeline()
+	if assert.Equal(t, 3, len(steps)) {
+		assert.Equal(t, time.Minute+5*time.Second, steps[1].Start)
+		assert.Equal(t, 2*time.Minute+7*time.Second, steps[2].Start)
+	}
+
+	// the next zone opens before the previous one closes
+	assert.Nil(t, p.SetDelay(nil, 3*time.Second))
+	steps = p.Timeline()
+	if assert.Equal(t, 3, len(steps)) {
+		assert.Equal(t, time.Duration(0), steps[0].Overlap)
+		assert.Equal(t, 57*time.Second, steps[1].Start)
+		assert.Equal(t, 3*time.Second, steps[1].Overlap)
+		assert.Equal(t, 3*time.Second, steps[1].Settle)
+		assert.Equal(t, time.Minute+57*time.Second, steps[2].Start)
+	}
+	assert.Equal(t, 3*time.Minute, p.Duration())
+}
+
+func TestProgramCycleSoak(t *testing.T) {
+	gpioStub := NewGpioStub()
+	core.InitGpio(gpioStub)
+	d1 := &core.Device{Name: "dev1", Pin: 1}
+	d2 := &core.Device{Name: "dev2", Pin: 2}
+	d1.Init()
+	d2.Init()
+
+	p := &core.Program{Name: "pr1"}
+	assert.Nil(t, p.AddElement(&core.ProgramElement{Device: d1, Duration: 2 * time.Second, Cycle: time.Second, Soak: 2 * time.Second}))
+	assert.Nil(t, p.AddDevice(d2, 1*time.Second))
+
+	p.Start()
+	time.Sleep(500 * time.Millisecond)
+	assert.True(t, d1.IsOn())
+	time.Sleep(1000 * time.Millisecond)
+	assert.False(t, d1.IsOn())
+	assert.False(t, d2.IsOn())
+	time.Sleep(1000 * time.Millisecond)
+	assert.False(t, d1.IsOn())
+	assert.True(t, d2.IsOn())
+	time.Sleep(1000 * time.Millisecond)
+	assert.False(t, d1.IsOn())
+	assert.False(t, d2.IsOn())
+	time.Sleep(1000 * time.Millisecond)
+	assert.True(t, d1.IsOn())
+	p.Stop()
+	assert.False(t, d1.IsOn())
+}
+
+func TestTimelineAdjustment(t *testing.T) {
+	data := core.NewData()
+	d1 := &core.Device{Name: "dev1"}
+	p := &core.Program{Name: "pr1"}
+	assert.Nil(t, p.AddDevice(d1, 10*time.Minute))
+
+	month := core.MonthKey(time.Now().Month())
+	assert.Equal(t, 100, p.Scale(time.Now()))
+
+	assert.Equal(t, core.InvalidAdjustment, data.Settings.SetAdjustment(core.Adjustment{"foo": 50}))
+	assert.Equal(t, core.InvalidAdjustment, data.Settings.SetAdjustment(core.Adjustment{month: -1}))
+	assert.Nil(t, data.Settings.SetAdjustment(core.Adjustment{month: 60}))
+	assert.Equal(t, 60, p.Scale(time.Now()))
+	assert.Equal(t, 6*time.Minute, p.Timeline()[0].Duration)
+
+	assert.Equal(t, core.InvalidAdjustment, p.SetAdjustment(core.Adjustment{"foo": 50}))
+	assert.Nil(t, p.SetAdjustment(core.Adjustment{month: 120}))
+	assert.Equal(t, 120, p.Scale(time.Now()))
+	assert.Equal(t, 12*time.Minute, p.Timeline()[0].Duration)
+
+	assert.Nil(t, p.SetAdjustment(core.Adjustment{month: 0}))
+	assert.Empty(t, p.Timeline())
+
+	core.NewData()
+}
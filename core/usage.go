@@ -0,0 +1,114 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+)
+
+var (
+	InvalidDay = errors.New("Invalid day, expected YYYY-MM-DD")
+)
+
+// dayLayout is the format of the days the water usage is accounted for
+const dayLayout = "2006-01-02"
+
+// ParseDay parses a day in the format of the usage accounting
+func ParseDay(str string) (time.Time, error) {
+	t, err := time.ParseInLocation(dayLayout, str, time.Local)
+	if err != nil {
+		return time.Time{}, InvalidDay
+	}
+	return t, nil
+}
+
+// DayUsage is the water used on a day in liters, per device and per program
+type DayUsage struct {
+	Devices  map[string]float64 `json:"devices,omitempty"`
+	Programs map[string]float64 `json:"programs,omitempty"`
+}
+
+// Usage accounts the water measured by the flow sensors, it is attributed to
+// the devices open at the time of the measurement and to the programs that
+// opened them
+type Usage struct {
+	Days map[string]*DayUsage `json:"days,omitempty"`
+	m    sync.Mutex
+}
+
+// UsageReport is the water used in liters between From and To days, inclusive
+type UsageReport struct {
+	From     string               `json:"from"`
+	To       string               `json:"to"`
+	Devices  map[string]float64   `json:"devices"`
+	Programs map[string]float64   `json:"programs"`
+	Days     map[string]*DayUsage `json:"days"`
+}
+
+func NewUsage() *Usage {
+	return &Usage{Days: make(map[string]*DayUsage)}
+}
+
+// Add accounts liters of water used at the time t by the device, the program
+// is empty for the manual runs
+func (u *Usage) Add(t time.Time, liters float64, device, program string) {
+	u.m.Lock()
+	defer u.m.Unlock()
+
+	if u.Days == nil {
+		u.Days = make(map[string]*DayUsage)
+	}
+	key := t.Format(dayLayout)
+	day, exists := u.Days[key]
+	if !exists {
+		day = &DayUsage{Devices: make(map[string]float64), Programs: make(map[string]float64)}
+		u.Days[key] = day
+	}
+	day.Devices[device] += liters
+	if program != "" {
+		day.Programs[program] += liters
+	}
+}
+
+// Report sums the water used between the days of from and to, inclusive
+func (u *Usage) Report(from, to time.Time) *UsageReport {
+	rep := &UsageReport{
+		From:     from.Format(dayLayout),
+		To:       to.Format(dayLayout),
+		Devices:  make(map[string]float64),
+		Programs: make(map[string]float64),
+		Days:     make(map[string]*DayUsage),
+	}
+
+	u.m.Lock()
+	defer u.m.Unlock()
+
+	for key, day := range u.Days {
+		if key < rep.From || key > rep.To {
+			continue
+		}
+		copied := &DayUsage{Devices: make(map[string]float64), Programs: make(map[string]float64)}
+		for name, liters := range day.Devices {
+			rep.Devices[name] += liters
+			copied.Devices[name] = liters
+		}
+		for name, liters := range day.Programs {
+			rep.Programs[name] += liters
+			copied.Programs[name] = liters
+		}
+		rep.Days[key] = copied
+	}
+	return rep
+}
+
+// MarshalJSON encodes the usage while holding its lock, the flow sensors
+// may add to it at any time
+func (u *Usage) MarshalJSON() ([]byte, error) {
+	type usage Usage
+
+	u.m.Lock()
+	defer u.m.Unlock()
+
+	return json.Marshal((*usage)(u))
+}
@@ -0,0 +1,111 @@
+package core_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/peter-vaczi/sprinkler/core"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUsageReport(t *testing.T) {
+	u := core.NewUsage()
+	day1 := time.Date(2026, 6, 1, 6, 0, 0, 0, time.Local)
+	day2 := time.Date(2026, 6, 2, 6, 0, 0, 0, time.Local)
+	day3 := time.Date(2026, 6, 3, 6, 0, 0, 0, time.Local)
+	u.Add(day1, 10, "dev1", "pr1")
+	u.Add(day2, 5, "dev1", "")
+	u.Add(day2, 20, "dev2", "pr1")
+	u.Add(day3, 40, "dev2", "pr1")
+
+	rep := u.Report(day1, day2)
+	assert.Equal(t, "2026-06-01", rep.From)
+	assert.Equal(t, "2026-06-02", rep.To)
+	assert.Equal(t, map[string]float64{"dev1": 15, "dev2": 20}, rep.Devices)
+	assert.Equal(t, map[string]float64{"pr1": 30}, rep.Programs)
+	assert.Len(t, rep.Days, 2)
+	assert.Equal(t, 10.0, rep.Days["2026-06-01"].Devices["dev1"])
+
+	_, err := core.ParseDay("06/01/2026")
+	assert.Equal(t, core.InvalidDay, err)
+	d, err := core.ParseDay("2026-06-03")
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]float64{"dev2": 40}, u.Report(d, d).Devices)
+}
+
+func TestFlowUsage(t *testing.T) {
+	gpioStub := NewGpioStub()
+	core.InitGpio(gpioStub)
+	data := core.NewData()
+	d1 := &core.Device{Name: "dev1", Pin: 1}
+	d2 := &core.Device{Name: "dev2", Pin: 2}
+	assert.Nil(t, data.Devices.Add(d1))
+	assert.Nil(t, data.Devices.Add(d2))
+	p := &core.Program{Name: "pr1"}
+	assert.Nil(t, p.AddDevice(d1, 10*time.Second))
+	assert.Nil(t, data.Programs.Add(p))
+
+	assert.Equal(t, core.InvalidSensor, data.Sensors.Add(&core.Sensor{Name: "flow", Type: core.SensorFlow, Pin: 6}))
+	flow := &core.Sensor{Name: "flow", Type: core.SensorFlow, Pin: 6, KFactor: 10}
+	assert.Nil(t, data.Sensors.Add(flow))
+	pulses := func(n int) {
+		for i := 0; i < n; i++ {
+			gpioStub.pins[6].setHigh(true)
+			time.Sleep(10 * time.Millisecond)
+			gpioStub.pins[6].setHigh(false)
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	// the flow is not accounted while every zone is closed
+	pulses(5)
+
+	p.Start()
+	time.Sleep(100 * time.Millisecond)
+	pulses(20)
+	p.Stop()
+
+	assert.Nil(t, d2.Run(time.Second))
+	time.Sleep(100 * time.Millisecond)
+	pulses(10)
+	d2.StopRun()
+
+	rep := data.Usage.Report(time.Now(), time.Now())
+	assert.InDelta(t, 2.0, rep.Devices["dev1"], 0.01)
+	assert.InDelta(t, 1.0, rep.Devices["dev2"], 0.01)
+	assert.Equal(t, map[string]float64{"pr1": rep.Devices["dev1"]}, rep.Programs)
+
+	data.Sensors.StopAll()
+	time.Sleep(100 * time.Millisecond)
+	core.NewData()
+}
+
+func TestFlowManualZone(t *testing.T) {
+	gpioStub := NewGpioStub()
+	core.InitGpio(gpioStub)
+	data := core.NewData()
+	assert.Nil(t, data.Settings.Set(&core.Settings{FlowWindow: 100 * time.Millisecond, LeakFlow: 10}))
+	d1 := &core.Device{Name: "dev1", Pin: 1}
+	assert.Nil(t, data.Devices.Add(d1))
+	assert.Nil(t, data.Sensors.Add(&core.Sensor{Name: "flow", Type: core.SensorFlow, Pin: 6, KFactor: 10}))
+	time.Sleep(200 * time.Millisecond)
+
+	// the zone switched on by hand is open, its water is not a leak
+	assert.Nil(t, data.Devices.Set("dev1", &core.Device{Pin: 1, On: true}))
+	for i := 0; i < 10; i++ {
+		gpioStub.pins[6].setHigh(true)
+		time.Sleep(10 * time.Millisecond)
+		gpioStub.pins[6].setHigh(false)
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.Nil(t, data.Devices.Set("dev1", &core.Device{Pin: 1, On: false}))
+
+	rep := data.Usage.Report(time.Now(), time.Now())
+	assert.InDelta(t, 1.0, rep.Devices["dev1"], 0.01)
+	assert.Empty(t, rep.Programs)
+	assert.Empty(t, data.Events.Get(time.Time{}))
+
+	data.Sensors.StopAll()
+	time.Sleep(100 * time.Millisecond)
+	core.NewData()
+}
@@ -25,10 +25,22 @@ func New() (Gpio, error) {
 	return &gpioImpl{}, nil
 }
 
+// Pull is the pull up/down resistor configuration of an input pin
+type Pull int
+
+const (
+	PullOff Pull = iota
+	PullDown
+	PullUp
+)
+
 type Pin interface {
 	Output()
 	High()
 	Low()
+	Input()
+	Pull(pull Pull)
+	Read() bool
 }
 
 type pin rpio.Pin
@@ -45,6 +57,19 @@ func (p pin) Low() {
 	rpio.Pin(p).Low()
 }
 
+func (p pin) Input() {
+	rpio.Pin(p).Input()
+}
+
+func (p pin) Pull(pull Pull) {
+	rpio.Pin(p).Pull(rpio.Pull(pull))
+}
+
+// Read returns true if the input pin is high
+func (p pin) Read() bool {
+	return rpio.Pin(p).Read() == rpio.High
+}
+
 type dummyGpioImpl struct {
 	pins map[int]*dummyPin
 }
@@ -63,8 +88,12 @@ type dummyPin struct {
 	pin    int
 	output bool
 	high   bool
+	pull   Pull
 }
 
-func (p *dummyPin) Output() { p.output = true }
-func (p *dummyPin) High()   { p.high = true }
-func (p *dummyPin) Low()    { p.high = false }
+func (p *dummyPin) Output()        { p.output = true }
+func (p *dummyPin) High()          { p.high = true }
+func (p *dummyPin) Low()           { p.high = false }
+func (p *dummyPin) Input()         { p.output = false }
+func (p *dummyPin) Pull(pull Pull) { p.pull = pull }
+func (p *dummyPin) Read() bool     { return p.high }